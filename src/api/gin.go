@@ -13,7 +13,10 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"jwt-sign/api/handlers"
 	"jwt-sign/configuration"
+	"jwt-sign/i18n"
+	"jwt-sign/web"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -55,9 +58,24 @@ func StartGin(ctx context.Context) {
 	}
 	router.Use(otelgin.Middleware("jwt-sign"))
 
-	// let's load the html crap
-	router.Static("/assets", "./assets") 
-	router.LoadHTMLGlob("templates/**")
+	// let's load the html crap, embedded in the binary and optionally overridden for branding
+	catalogs, err := web.LoadCatalogs(conf.WebOverrideDir)
+	if err != nil {
+		log.Fatalf("unable to load message catalogs: %s", err.Error())
+	}
+	for locale, keys := range catalogs.Missing() {
+		log.Warnf("catalog %s has no translation for %s, falling back to %s", locale, strings.Join(keys, ", "), i18n.Fallback)
+	}
+	router.Use(i18n.Middleware(catalogs))
+	tmpl, err := web.LoadTemplates(conf.WebOverrideDir, catalogs)
+	if err != nil {
+		log.Fatalf("unable to load html templates: %s", err.Error())
+	}
+	router.SetHTMLTemplate(tmpl)
+	router.StaticFS("/assets", web.Assets(conf.WebOverrideDir))
+
+	// public keys for relying parties
+	router.GET("/.well-known/jwks.json", handlers.Jwks)
 
 	// Set up the groups
 	userAPI := router.Group("/v1")
@@ -69,6 +87,32 @@ func StartGin(ctx context.Context) {
 		// signature validate
 		userAPI.POST("/verify-signature", handlers.VerifySignature)
 
+		// selective disclosure of signed answers
+		userAPI.POST("/verify-presentation", handlers.VerifyPresentation)
+
+		// RFC 7662 token introspection and RFC 7009 revocation, only served to clients authenticated with basic auth
+		if accounts := introspectionAccounts(conf.IntrospectionClients); len(accounts) > 0 {
+			userAPI.POST("/introspect", gin.BasicAuth(accounts), handlers.Introspect)
+			userAPI.POST("/revoke", gin.BasicAuth(accounts), handlers.Revoke)
+		} else {
+			log.Warnf("No introspection clients configured, /v1/introspect and /v1/revoke are disabled")
+		}
+
+		// token issuance, meant for test environments
+		if conf.TokenIssuanceEnabled {
+			log.Warnf("Token issuance is active! Anyone reaching the API can mint tokens")
+			userAPI.POST("/tokens", handlers.IssueToken)
+		}
+
+	}
+
+	// structured answers
+	userAPIv2 := router.Group("/v2")
+	{
+
+		// validate
+		userAPIv2.POST("/validate-jwt", handlers.ValidateJwtV2)
+
 	}
 
 	// Activate swagger if configured
@@ -107,3 +151,15 @@ func StartGin(ctx context.Context) {
 		log.Infof("HTTP Server was shutdown successfully")
 	}
 }
+
+// introspectionAccounts parses the client_id:client_secret pairs allowed to call the introspection and revocation
+// endpoints.
+func introspectionAccounts(clients []string) gin.Accounts {
+	accounts := gin.Accounts{}
+	for _, client := range clients {
+		if id, secret, ok := strings.Cut(client, ":"); ok && id != "" && secret != "" {
+			accounts[id] = secret
+		}
+	}
+	return accounts
+}
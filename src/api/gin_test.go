@@ -0,0 +1,17 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntrospectionAccounts(t *testing.T) {
+	accounts := introspectionAccounts([]string{"gateway:s3cret", "missing-secret", ":no-id", "empty:", "colon:in:secret"})
+	assert.Equal(t, gin.Accounts{"gateway": "s3cret", "colon": "in:secret"}, accounts)
+
+	// without a well formed client introspection and revocation are not served
+	assert.Empty(t, introspectionAccounts(nil))
+	assert.Empty(t, introspectionAccounts([]string{"gateway"}))
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"github.com/gin-gonic/gin"
+	"jwt-sign/configuration"
+	"jwt-sign/i18n"
+	"jwt-sign/keystore"
+	"jwt-sign/privacy"
+	"jwt-sign/questionnaire"
+	"jwt-sign/replay"
+	"jwt-sign/revocation"
+	"jwt-sign/signer"
+	"jwt-sign/store"
+	"jwt-sign/token"
+	"jwt-sign/web"
+)
+
+// testSecret signs the tokens presented in the handler tests.
+const testSecret = "handlers-test-secret"
+
+// setupRouter initializes the packages the handlers depend on from a copy of the application configuration, after
+// applying configure to it, and returns a router serving the html pages without any route. The background work of
+// the packages and handlers is stopped and waited for when the test ends, before the next test initializes them again.
+func setupRouter(t *testing.T, configure func(conf *configuration.Configuration)) *gin.Engine {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		concurrency.GlobalWaitGroup.Wait()
+	})
+	logger.Init(ctx, false, false)
+	conf := *configuration.AppConfig()
+	conf.JwtHmacSecret = testSecret
+	conf.RevocationPruneSec = 0
+	conf.ReplayPruneSec = 0
+	if configure != nil {
+		configure(&conf)
+	}
+	if err := keystore.Init(ctx, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := signer.Init(&conf, keystore.Default()); err != nil {
+		t.Fatal(err)
+	}
+	if err := revocation.Init(ctx, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := token.Init(ctx, &conf, keystore.Default(), revocation.Default()); err != nil {
+		t.Fatal(err)
+	}
+	if err := replay.Init(ctx, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := questionnaire.Init(&conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Init(&conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := privacy.Init(&conf); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("correlation_id", t.Name()) })
+	catalogs, err := web.LoadCatalogs(conf.WebOverrideDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.Use(i18n.Middleware(catalogs))
+	tmpl, err := web.LoadTemplates(conf.WebOverrideDir, catalogs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetHTMLTemplate(tmpl)
+	return router
+}
+
+// postJSON posts the body as JSON, asking for a JSON response, and returns the status code and the response body.
+func postJSON(router *gin.Engine, path string, body interface{}) (int, []byte) {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code, w.Body.Bytes()
+}
+
+// postForm posts the form values, as the RFC 7662 and RFC 7009 endpoints expect, and returns the status code and the
+// response body.
+func postForm(router *gin.Engine, path string, values url.Values) (int, []byte) {
+	req := httptest.NewRequest("POST", path, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code, w.Body.Bytes()
+}
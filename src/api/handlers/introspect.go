@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"github.com/danbordeanu/go-utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"jwt-sign/api/response"
+	"jwt-sign/model"
+	"jwt-sign/token"
+)
+
+// Introspect godoc
+// @Summary Introspect token
+// @Description RFC 7662 token introspection. The token goes through the same validation as validate-jwt, inactive tokens only return active=false
+// @ID introspect
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "The token to introspect"
+// @Param token_type_hint formData string false "Hint about the type of the token"
+// @Success 200 {object} model.IntrospectionResult "The introspection result"
+// @Failure 400 {object} model.JSONFailureResult "The payload is invalid"
+// @Failure 401 "The client credentials are missing or invalid"
+// @Router /v1/introspect [post]
+func Introspect(c *gin.Context) {
+	concurrency.GlobalWaitGroup.Add(1)
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "handlers", "action", "Introspect")
+
+	var (
+		e             error
+		err           error
+		rr            model.Introspection
+		ctx           = c.Request.Context()
+		correlationId = c.MustGet("correlation_id").(string)
+	)
+	_, span := tracer.Start(ctx, "Token introspection",
+		oteltrace.WithAttributes(attribute.String("CorrelationId", correlationId)))
+	defer span.End()
+
+	// validate params
+	if err = c.ShouldBind(&rr); err != nil {
+		e = fmt.Errorf("error while parsing request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		return
+	}
+	if err = rr.Validate(); err != nil {
+		e = fmt.Errorf("error while validating request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		return
+	}
+
+	// the response must never be cached, the token state may change at any time
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	span.AddEvent("Decode Jwt Token")
+	jwtToken, err := token.Verify(rr.Token)
+	if err != nil {
+		// the reason stays in the logs and the trace, the client only learns the token is inactive
+		log.Debugf("inactive token: %s", err.Error())
+		span.SetAttributes(attribute.Bool("token.active", false), attribute.String("token.error", string(tokenErrorCode(err))))
+		c.JSON(http.StatusOK, model.IntrospectionResult{Active: false})
+		return
+	}
+	span.SetAttributes(attribute.Bool("token.active", true))
+
+	c.JSON(http.StatusOK, introspectionResult(jwtToken.Claims.(jwt.MapClaims)))
+}
+
+// introspectionResult maps the claims of an active token to the RFC 7662 response members.
+func introspectionResult(claims jwt.MapClaims) model.IntrospectionResult {
+	result := model.IntrospectionResult{Active: true, TokenType: "Bearer", Aud: claims["aud"]}
+	result.Sub, _ = claims["sub"].(string)
+	result.Iss, _ = claims["iss"].(string)
+	result.Jti, _ = claims["jti"].(string)
+	result.Username = result.Sub
+	result.Exp = numericClaim(claims, "exp")
+	result.Iat = numericClaim(claims, "iat")
+	result.Nbf = numericClaim(claims, "nbf")
+
+	if clientId, ok := claims["client_id"].(string); ok {
+		result.ClientId = clientId
+	} else {
+		result.ClientId, _ = claims["azp"].(string)
+	}
+
+	switch scope := claims["scope"].(type) {
+	case string:
+		result.Scope = scope
+	case []interface{}:
+		result.Scope = joinStrings(scope)
+	default:
+		if scp, ok := claims["scp"].([]interface{}); ok {
+			result.Scope = joinStrings(scp)
+		} else {
+			result.Scope, _ = claims["scp"].(string)
+		}
+	}
+	return result
+}
+
+// numericClaim returns a NumericDate claim in seconds, 0 when absent.
+func numericClaim(claims jwt.MapClaims, name string) int64 {
+	if v, ok := claims[name].(float64); ok {
+		return int64(v)
+	}
+	return 0
+}
+
+// joinStrings joins the string values of a JSON array with spaces, as scopes are represented in RFC 7662.
+func joinStrings(values []interface{}) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/model"
+)
+
+func TestIntrospectActiveToken(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/introspect", Introspect)
+
+	exp := time.Now().Add(time.Minute).Unix()
+	raw := signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "jti": "introspected", "exp": exp, "azp": "gateway",
+		"scp": []string{"read", "write"}})
+	status, body := postForm(router, "/v1/introspect", url.Values{"token": {raw}})
+	require.Equal(t, http.StatusOK, status)
+
+	var result model.IntrospectionResult
+	require.NoError(t, json.Unmarshal(body, &result))
+	assert.Equal(t, model.IntrospectionResult{
+		Active:    true,
+		Scope:     "read write",
+		ClientId:  "gateway",
+		Username:  "JonnyBoy",
+		TokenType: "Bearer",
+		Exp:       exp,
+		Sub:       "JonnyBoy",
+		Jti:       "introspected",
+	}, result)
+}
+
+func TestIntrospectInactiveToken(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/introspect", Introspect)
+
+	for name, raw := range map[string]string{
+		"expired":   signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(-time.Hour).Unix()}),
+		"malformed": "not-a-token",
+		"forged":    signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(time.Minute).Unix()}) + "x",
+	} {
+		status, body := postForm(router, "/v1/introspect", url.Values{"token": {raw}})
+		assert.Equal(t, http.StatusOK, status, name)
+		assert.JSONEq(t, `{"active":false}`, string(body), name)
+	}
+
+	status, _ := postForm(router, "/v1/introspect", url.Values{})
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"jwt-sign/api/response"
+	"jwt-sign/model"
+	"jwt-sign/signer"
+)
+
+// IssueToken godoc
+// @Summary Issue token
+// @Description Mint a signed JWT, or a v4 PASETO, for a subject, only available when token issuance is enabled
+// @ID issueToken
+// @Accept json
+// @Produce json
+// @Param model.TokenIssuance body model.TokenIssuance true "token request"
+// @Success 200 {object} model.JSONSuccessResult{data=model.IssuedToken} "The token was issued"
+// @Failure 400 {object} model.JSONFailureResult{data=model.ValidationFailure} "The payload is invalid or violates the issuance policy"
+// @Failure 500 {object} model.JSONFailureResult{data=model.ValidationFailure} "The token could not be signed"
+// @Router /v1/tokens [post]
+func IssueToken(c *gin.Context) {
+	concurrency.GlobalWaitGroup.Add(1)
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "handlers", "action", "IssueToken")
+
+	var (
+		e             error
+		err           error
+		rr            model.TokenIssuance
+		ctx           = c.Request.Context()
+		correlationId = c.MustGet("correlation_id").(string)
+	)
+	_, span := tracer.Start(ctx, "Token issuance",
+		oteltrace.WithAttributes(attribute.String("CorrelationId", correlationId)))
+	defer span.End()
+
+	// validate params
+	if err = c.ShouldBindJSON(&rr); err != nil {
+		e = fmt.Errorf("error while parsing request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorJSONResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+	if err = rr.Validate(); err != nil {
+		e = fmt.Errorf("error while validating request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorJSONResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+
+	span.AddEvent("Sign token")
+	issued, err := signer.Issue(rr.Subject, rr.Audience, rr.Claims, time.Duration(rr.TtlSec)*time.Second,
+		rr.Format)
+	if errors.Is(err, signer.ErrIssuancePolicy) {
+		e = fmt.Errorf("error while issuing token: %s", err.Error())
+		log.Debugf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorJSONResponse(c, response.NewError(response.KindValidation, response.ErrCodeIssuanceRejected, e))
+		return
+	} else if err != nil {
+		e = fmt.Errorf("error while signing token: %s", err.Error())
+		log.Errorf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorJSONResponse(c, response.NewError(response.KindSigning, response.ErrCodeSigningFailed, e))
+		return
+	}
+	span.SetAttributes(attribute.String("token.kid", issued.KeyId), attribute.String("token.alg", issued.Algorithm))
+	log.Debugf("issued token for subject %s with key %s", rr.Subject, issued.KeyId)
+
+	response.SuccessResponse(c, model.IssuedToken{
+		Token:     issued.Token,
+		TokenType: "Bearer",
+		ExpiresIn: int64(time.Until(issued.ExpiresAt).Seconds()),
+		ExpiresAt: issued.ExpiresAt.Unix(),
+		KeyId:     issued.KeyId,
+		Algorithm: issued.Algorithm,
+	})
+}
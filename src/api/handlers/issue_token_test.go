@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/api/response"
+	"jwt-sign/configuration"
+	"jwt-sign/model"
+)
+
+func TestIssueToken(t *testing.T) {
+	router := setupRouter(t, func(conf *configuration.Configuration) {
+		conf.SigningAlgorithm = "ES256"
+		conf.TokenIssuanceEnabled = true
+		conf.TokenMaxTtlSec = 3600
+		conf.PasetoLocalKey = ""
+	})
+	router.POST("/v1/tokens", IssueToken)
+
+	for name, tc := range map[string]struct {
+		request model.TokenIssuance
+		status  int
+		code    string
+	}{
+		"jwt":              {model.TokenIssuance{Subject: "JonnyBoy", TtlSec: 60}, http.StatusOK, ""},
+		"default ttl":      {model.TokenIssuance{Subject: "JonnyBoy"}, http.StatusOK, ""},
+		"missing subject":  {model.TokenIssuance{TtlSec: 60}, http.StatusBadRequest, response.ErrCodeInvalidRequest},
+		"negative ttl":     {model.TokenIssuance{Subject: "JonnyBoy", TtlSec: -1}, http.StatusBadRequest, response.ErrCodeInvalidRequest},
+		"ttl too long":     {model.TokenIssuance{Subject: "JonnyBoy", TtlSec: 7200}, http.StatusBadRequest, response.ErrCodeIssuanceRejected},
+		"reserved claim":   {model.TokenIssuance{Subject: "JonnyBoy", Claims: map[string]interface{}{"exp": 1}}, http.StatusBadRequest, response.ErrCodeIssuanceRejected},
+		"answers claim":    {model.TokenIssuance{Subject: "JonnyBoy", Claims: map[string]interface{}{"answers_digest": "d"}}, http.StatusBadRequest, response.ErrCodeIssuanceRejected},
+		"answers format":   {model.TokenIssuance{Subject: "JonnyBoy", Format: "sd-jwt"}, http.StatusBadRequest, response.ErrCodeIssuanceRejected},
+		"no ed25519 key":   {model.TokenIssuance{Subject: "JonnyBoy", Format: "v4.public"}, http.StatusInternalServerError, response.ErrCodeSigningFailed},
+		"no paseto secret": {model.TokenIssuance{Subject: "JonnyBoy", Format: "v4.local"}, http.StatusInternalServerError, response.ErrCodeSigningFailed},
+	} {
+		status, body := postJSON(router, "/v1/tokens", tc.request)
+		assert.Equal(t, tc.status, status, "%s: %s", name, body)
+		if tc.code == "" {
+			continue
+		}
+		var result struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(body, &result), name)
+		assert.Equal(t, tc.code, result.Data["errorCode"], name)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"github.com/danbordeanu/go-utils"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"jwt-sign/api/response"
+	"jwt-sign/configuration"
+	"jwt-sign/keystore"
+)
+
+// Jwks godoc
+// @Summary JSON Web Key Set
+// @Description Public keys relying parties use to verify the signatures produced by validate-jwt
+// @ID jwks
+// @Produce json
+// @Success 200 {object} object "The JSON Web Key Set"
+// @Success 304 "The key set did not change since the ETag sent in If-None-Match"
+// @Router /.well-known/jwks.json [get]
+func Jwks(c *gin.Context) {
+	concurrency.GlobalWaitGroup.Add(1)
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "handlers", "action", "Jwks")
+
+	var (
+		conf          = configuration.AppConfig()
+		ctx           = c.Request.Context()
+		correlationId = c.MustGet("correlation_id").(string)
+	)
+	_, span := tracer.Start(ctx, "JWKS",
+		oteltrace.WithAttributes(attribute.String("CorrelationId", correlationId)))
+	defer span.End()
+
+	set := keystore.PublicJwks(keystore.Default().Keys())
+	body, err := json.Marshal(set)
+	if err != nil {
+		e := fmt.Errorf("error while encoding the key set: %s", err.Error())
+		log.Errorf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.FailureResponse(c, nil, utils.HttpError{Code: 500, Err: e})
+		return
+	}
+	span.SetAttributes(attribute.Int("jwks.keys", len(set.Keys)))
+
+	// the ETag changes with the key set, so caches pick up rotated keys once max-age expires
+	sum := sha256.Sum256(body)
+	etag := fmt.Sprintf("%q", base64.RawURLEncoding.EncodeToString(sum[:]))
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", conf.JwksMaxAgeSec))
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", body)
+}
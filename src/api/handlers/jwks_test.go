@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"jwt-sign/configuration"
+)
+
+// getJwks fetches the key set, sending the ETag in If-None-Match when not empty.
+func getJwks(router http.Handler, etag string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestJwks(t *testing.T) {
+	dir := t.TempDir()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "k1.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "k2.jwk"),
+		[]byte(`{"kty":"oct","kid":"k2","alg":"HS256","k":"c2VjcmV0LXNlY3JldC1zZWNyZXQtc2VjcmV0LXNlY3JldA"}`), 0600))
+
+	router := setupRouter(t, func(conf *configuration.Configuration) {
+		conf.KeyStoreDir = dir
+		conf.SigningKeyId = "k1"
+	})
+	router.GET("/.well-known/jwks.json", Jwks)
+
+	w := getJwks(router, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, fmt.Sprintf("public, max-age=%d", configuration.AppConfig().JwksMaxAgeSec), w.Header().Get("Cache-Control"))
+
+	// the public half of the signing key only, the shared secret is never published
+	var set jose.JSONWebKeySet
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 1)
+	assert.Equal(t, "k1", set.Keys[0].KeyID)
+	assert.Equal(t, "ES256", set.Keys[0].Algorithm)
+	assert.True(t, set.Keys[0].IsPublic())
+	assert.Equal(t, &private.PublicKey, set.Keys[0].Key)
+	var raw struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &raw))
+	assert.NotContains(t, raw.Keys[0], "d")
+
+	// the ETag of an unchanged key set answers 304, any other one gets the key set again
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	w = getJwks(router, etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+	w = getJwks(router, `"stale"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"github.com/danbordeanu/go-utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"jwt-sign/api/response"
+	"jwt-sign/model"
+	"jwt-sign/revocation"
+	"jwt-sign/token"
+)
+
+// Revoke godoc
+// @Summary Revoke token
+// @Description RFC 7009 token revocation. The jti of a valid token is denylisted until the token expires; invalid, expired or already revoked tokens are ignored
+// @ID revoke
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "The token to revoke"
+// @Param token_type_hint formData string false "Hint about the type of the token"
+// @Success 200 "The token is no longer valid"
+// @Failure 400 {object} model.JSONFailureResult "The payload is invalid or the token has no jti"
+// @Failure 401 "The client credentials are missing or invalid"
+// @Failure 500 {object} model.JSONFailureResult "The revocation could not be stored"
+// @Router /v1/revoke [post]
+func Revoke(c *gin.Context) {
+	concurrency.GlobalWaitGroup.Add(1)
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "handlers", "action", "Revoke")
+
+	var (
+		e             error
+		err           error
+		rr            model.Revocation
+		ctx           = c.Request.Context()
+		correlationId = c.MustGet("correlation_id").(string)
+	)
+	_, span := tracer.Start(ctx, "Token revocation",
+		oteltrace.WithAttributes(attribute.String("CorrelationId", correlationId)))
+	defer span.End()
+
+	// validate params
+	if err = c.ShouldBind(&rr); err != nil {
+		e = fmt.Errorf("error while parsing request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		return
+	}
+	if err = rr.Validate(); err != nil {
+		e = fmt.Errorf("error while validating request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		return
+	}
+
+	// only genuine tokens are denylisted, anything that does not verify is already unusable
+	span.AddEvent("Decode Jwt Token")
+	jwtToken, err := token.Verify(rr.Token)
+	if err != nil {
+		log.Debugf("ignoring revocation of an invalid token: %s", err.Error())
+		span.SetAttributes(attribute.String("token.error", string(tokenErrorCode(err))))
+		c.Status(http.StatusOK)
+		return
+	}
+	claims := jwtToken.Claims.(jwt.MapClaims)
+	issuer, _ := claims["iss"].(string)
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		e = fmt.Errorf("token has no jti and cannot be revoked")
+		span.SetStatus(codes.Error, e.Error())
+		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		return
+	}
+	// the jti of the issuer is denylisted for as long as the claims policy accepts the token, leeway included
+	expiresAt := token.AcceptedUntil(claims)
+
+	span.AddEvent("Store revocation")
+	span.SetAttributes(attribute.String("token.iss", issuer), attribute.String("token.jti", jti))
+	if err = revocation.Default().Revoke(issuer, jti, expiresAt); err != nil {
+		e = fmt.Errorf("error while storing revocation: %s", err.Error())
+		log.Errorf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.FailureResponse(c, nil, utils.HttpError{Code: 500, Err: e})
+		return
+	}
+	log.Infof("revoked token %s of issuer %q", jti, issuer)
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/configuration"
+	"jwt-sign/model"
+	"jwt-sign/revocation"
+)
+
+func TestRevokeWithinLeeway(t *testing.T) {
+	router := setupRouter(t, func(conf *configuration.Configuration) {
+		conf.JwtLeewaySec = 60
+	})
+	router.POST("/v1/revoke", Revoke)
+	router.POST("/v1/validate-jwt", ValidateJwt)
+
+	// the token expired 5 seconds ago but is still accepted thanks to the leeway
+	raw := signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "jti": "revoked-jti", "exp": time.Now().Add(-5 * time.Second).Unix()})
+	status, _ := postForm(router, "/v1/revoke", url.Values{"token": {raw}})
+	require.Equal(t, http.StatusOK, status)
+
+	// the revocation outlives the exp claim for as long as the leeway accepts the token
+	pruned, err := revocation.Default().Prune(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, pruned)
+	status, _ = postJSON(router, "/v1/validate-jwt", model.JwtValidation{
+		Jwt:       raw,
+		Questions: []string{"question1"},
+		Answers:   []string{"answer1"},
+	})
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestRevokeRequiresJti(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/revoke", Revoke)
+
+	raw := signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(time.Minute).Unix()})
+	status, _ := postForm(router, "/v1/revoke", url.Values{"token": {raw}})
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// tokens that do not verify are ignored
+	status, _ = postForm(router, "/v1/revoke", url.Values{"token": {raw + "x"}})
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestRevokeScopesJtiToIssuer(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/revoke", Revoke)
+	router.POST("/v1/validate-jwt", ValidateJwt)
+
+	tokenOf := func(issuer string) string {
+		return signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "iss": issuer, "jti": "shared-jti",
+			"exp": time.Now().Add(time.Minute).Unix()})
+	}
+	status, _ := postForm(router, "/v1/revoke", url.Values{"token": {tokenOf("https://idp.example")}})
+	require.Equal(t, http.StatusOK, status)
+
+	// the token of another issuer carrying the same jti is not revoked
+	for issuer, expected := range map[string]int{
+		"https://idp.example":   http.StatusUnauthorized,
+		"https://other.example": http.StatusOK,
+	} {
+		status, body := postJSON(router, "/v1/validate-jwt", model.JwtValidation{
+			Jwt:       tokenOf(issuer),
+			Questions: []string{"question1"},
+			Answers:   []string{"answer1"},
+		})
+		assert.Equal(t, expected, status, "%s: %s", issuer, body)
+	}
+}
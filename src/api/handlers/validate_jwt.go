@@ -1,26 +1,42 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/danbordeanu/go-logger"
 	"github.com/danbordeanu/go-stats/concurrency"
-	"github.com/danbordeanu/go-utils"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	oteltrace "go.opentelemetry.io/otel/trace"
 	"jwt-sign/api/response"
 	"jwt-sign/configuration"
 	"jwt-sign/model"
+	"jwt-sign/privacy"
+	"jwt-sign/questionnaire"
+	"jwt-sign/replay"
+	"jwt-sign/signer"
+	"jwt-sign/store"
+	"jwt-sign/token"
+	"strings"
+	"time"
 )
 
 // ValidateJwt godoc
 // @Summary Validate jwt
 // @Description Validate Jwt
 // @ID  validateJwt
-// @Produce html
+// @Produce html,json
 // @Param model.JwtValidation body model.JwtValidation true "validate signature"
-// @Success 200 {object} model.JSONSuccessResult "The request was validated and has been processed successfully (sync)"
+// @Param lang query string false "Locale of the html page, takes precedence over the Accept-Language header"
+// @Success 200 {object} model.JSONSuccessResult{data=model.AnswersSignature} "The request was validated and has been processed successfully (sync). The html page is rendered unless the Accept header prefers application/json"
+// @Failure 400 {object} model.JSONFailureResult{data=model.ValidationFailure} "The payload is invalid or the answers do not match the questionnaire"
+// @Failure 401 {object} model.JSONFailureResult "The jwt is malformed, unsigned, expired, revoked, its signature is invalid or its claims are not accepted"
+// @Failure 409 {object} model.JSONFailureResult "The jwt was already used"
+// @Failure 500 {object} model.JSONFailureResult "The answers could not be signed, or the token nonce or the onboarding record could not be stored"
 // @Router /v1/validate-jwt [post]
 func ValidateJwt(c *gin.Context) {
 	concurrency.GlobalWaitGroup.Add(1)
@@ -44,82 +60,458 @@ func ValidateJwt(c *gin.Context) {
 		e = fmt.Errorf("error while parsing request: %s", err.Error())
 		span.SetStatus(codes.Error, e.Error())
 		span.RecordError(err)
-		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
 		return
 	}
 	if err = rr.Validate(); err != nil {
 		e = fmt.Errorf("error while validating request: %s", err.Error())
 		span.SetStatus(codes.Error, e.Error())
 		span.RecordError(err)
-		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
 		return
 	}
+	if err = checkFormat(rr.Format, signer.SignatureFormats); err != nil {
+		e = fmt.Errorf("error while validating request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+
+	recipient, err := parseRecipient(rr.EncryptTo)
+	if err != nil {
+		e = fmt.Errorf("error while validating request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+
+	onboard(c, ctx, span, log, rr.Jwt, &pairsSubmission{questions: rr.Questions, answers: rr.Answers}, rr.Format,
+		recipient)
+}
+
+// submission is the set of answers posted along with a jwt, in one of the request models.
+type submission interface {
+	// validate checks the answers against the questionnaire, or only that they are well formed when q is nil
+	validate(q *questionnaire.Questionnaire) error
+	// sign signs the answers bound to the subject in the token format
+	sign(c *gin.Context, subject, format string) (*signer.SignedAnswers, error)
+	// record returns the questions and answers stored in the onboarding record
+	record() ([]string, []string)
+}
+
+// pairsSubmission holds answers posted as parallel lists of questions and answers.
+type pairsSubmission struct {
+	questions []string
+	answers   []string
+}
+
+func (s *pairsSubmission) validate(q *questionnaire.Questionnaire) error {
+	if q == nil {
+		if err := questionnaire.CheckCount(s.questions, s.answers); err != nil {
+			return err
+		}
+		return nil
+	}
+	return q.Validate(s.questions, s.answers)
+}
+
+func (s *pairsSubmission) sign(c *gin.Context, subject, format string) (*signer.SignedAnswers, error) {
+	return SignAnswers(c, subject, s.questions, s.answers, format)
+}
+
+func (s *pairsSubmission) record() ([]string, []string) {
+	return s.questions, s.answers
+}
+
+// onboard verifies the jwt, validates and signs the submitted answers and renders the outcome. It is shared by the
+// versions of the validate-jwt endpoint once their request is parsed.
+//
+// Parameters:
+//   - c *gin.Context: Gin context of the request
+//   - ctx context.Context: Context of the request span
+//   - span oteltrace.Span: Span of the request
+//   - log *logger.CSugaredLogger: Logger of the handler
+//   - rawJwt string: The presented jwt, signed or encrypted
+//   - sub submission: The submitted answers
+//   - format string: Format of the signature, the format configured for the issuer of the jwt when empty
+//   - recipient *signer.Recipient: Recipient the signature is encrypted to, nil to return it in clear
+func onboard(c *gin.Context, ctx context.Context, span oteltrace.Span, log *logger.CSugaredLogger, rawJwt string,
+	sub submission, format string, recipient *signer.Recipient) {
+	var (
+		err           error
+		e             error
+		correlationId = c.MustGet("correlation_id").(string)
+	)
 
 	span.AddEvent("New Jwt Provider")
 
 	// decode jwt token received in post request
 	span.AddEvent("Decode Jwt Token")
+	jwtToken, err := token.Verify(rawJwt)
+	if err != nil {
+		e = fmt.Errorf("error while verifying jwt: %s", err.Error())
+		log.Debugf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, tokenFailure(err, e))
+		return
+	}
+	span.SetAttributes(attribute.String("jwt.alg", jwtToken.Method.Alg()))
 
-	// query db by jwt and get ravendb ID
-	span.AddEvent("Query Db after jwt")
+	// decide what type of jwt we have
+	span.AddEvent("Unmarshal JWT")
+	claims := jwtToken.Claims.(jwt.MapClaims)
+	subject, _ := claims["sub"].(string)
+	issuer, _ := claims["iss"].(string)
+	jti, _ := claims["jti"].(string)
 
-	// load document by ravenID
-	span.AddEvent("Load Document By Id")
+	// the signature format defaults to the one configured for the issuer, only compact JWS can be encrypted
+	if format == "" {
+		format = signer.SignatureFormat(issuer)
+	}
+	if recipient != nil && format != signer.TokenFormatJwt {
+		e = fmt.Errorf("error while validating request: encryptTo requires jwt signatures, got format %s", format)
+		log.Debugf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+	span.SetAttributes(attribute.String("signature.format", format))
 
-	// decode jwt stored in ravendb
-	span.AddEvent("Decode JWT stored in db")
+	// validate the answers against the questionnaire referenced by the jwt, before the token is used up
+	span.AddEvent("Validate answers")
+	questionnaireId, err := validateAnswers(claims, sub)
+	if err != nil {
+		e = fmt.Errorf("error while validating answers: %s", err.Error())
+		log.Debugf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		failure := response.NewError(response.KindValidation, response.ErrCodeAnswersInvalid, e)
+		var ve *questionnaire.ValidationError
+		if errors.As(err, &ve) {
+			failure.Fields = ve.Fields
+		}
+		response.ErrorResponse(c, failure)
+		return
+	}
 
-	// decide what type of jwt we have 
-	span.AddEvent("Unmarshal JWT")
+	// a token is accepted once, concurrent submissions of the same token race for the nonce. The nonce of an encrypted
+	// token is taken from the jws it nests, which stays the same when the token is encrypted anew. The nonce is kept
+	// as long as the claims policy accepts the token, leeway included, and a token must not be claimed past that time
+	// or its nonce could already have been forgotten
+	span.AddEvent("Claim token nonce")
+	nonce := replay.Key(jwtToken.Raw, issuer, jti)
+	if nonces := replay.Default(); nonces != nil {
+		expiresAt := token.AcceptedUntil(claims)
+		if !expiresAt.IsZero() && !time.Now().Before(expiresAt) {
+			e = fmt.Errorf("token expired at %s", expiresAt.UTC().Format(time.RFC3339))
+			log.Debugf("%s", e)
+			span.SetStatus(codes.Error, e.Error())
+			response.ErrorResponse(c, response.NewError(response.KindExpired, string(token.ErrCodeExpired), e))
+			return
+		}
+		claimed, err := nonces.Claim(nonce, expiresAt)
+		if err != nil {
+			e = fmt.Errorf("error while claiming token nonce: %s", err.Error())
+			log.Errorf("%s", e)
+			span.SetStatus(codes.Error, e.Error())
+			span.RecordError(err)
+			response.ErrorResponse(c, response.NewError(response.KindInternal, response.ErrCodeInternal, e))
+			return
+		}
+		if !claimed {
+			log.Infof("rejecting replayed token %s", nonce)
+			span.SetStatus(codes.Error, "token already used")
+			response.ErrorResponse(c, response.NewError(response.KindAlreadyUsed, response.ErrCodeAlreadyUsed, fmt.Errorf("token already used")))
+			return
+		}
+	}
 
-	// Retrieve the questions and answers from the query parameters
-	questions := rr.Questions
-	answers := rr.Answers
+	// Retrieve the questions and answers of the submission, answers are only persisted hashed
+	questions, answers := sub.record()
+	hasher := privacy.Default()
+	salt, hashes, err := hasher.HashAnswers(answers)
+	if err != nil {
+		e = fmt.Errorf("error while hashing answers: %s", err.Error())
+		log.Errorf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		releaseNonce(c, nonce)
+		response.ErrorResponse(c, response.NewError(response.KindInternal, response.ErrCodeInternal, e))
+		return
+	}
 
-	// Sign the answers using some logic
-	testSignature, err := SignAnswers(c, questions, answers)
+	// persist the validation, linked to the correlation id of the request
+	span.AddEvent("Store onboarding record")
+	record := &store.Record{
+		CorrelationId:  correlationId,
+		Subject:        subject,
+		TokenId:        jti,
+		Token:          rawJwt,
+		Questionnaire:  questionnaireId,
+		Questions:      questions,
+		AnswersSalt:    salt,
+		AnswersHashAlg: hasher.Algorithm(),
+		AnswerHashes:   hashes,
+		Status:         store.StatusReceived,
+	}
+	if err = store.Default().Save(record); err != nil {
+		e = fmt.Errorf("error while storing onboarding record: %s", err.Error())
+		log.Errorf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		releaseNonce(c, nonce)
+		response.ErrorResponse(c, response.NewError(response.KindInternal, response.ErrCodeInternal, e))
+		return
+	}
+
+	// Sign the answers bound to the subject of the jwt
+	signed, err := sub.sign(c, subject, format)
 	if err != nil {
 		e = fmt.Errorf("failed to sign answers: %s", err)
 		log.Errorf("%s", e)
 		span.SetStatus(codes.Error, e.Error())
 		span.RecordError(err)
-		response.RegistrationHtmlResponse(c, configuration.HtmlJwtValidationSuccessPage, "", "failed", "")
+		record.Status = store.StatusFailed
+		saveRecord(c, record)
+		releaseNonce(c, nonce)
+		response.ErrorResponse(c, response.NewError(response.KindSigning, response.ErrCodeSigningFailed, e))
 		return
 	}
 
-	log.Debugf("we got signature:%s", testSignature)
+	log.Debugf("we got signature:%s", privacy.Fingerprint(signed.Signature))
+
+	// encrypt the signature to the recipient. The disclosures of an SD-JWT are handed out only
+	output := signed.Token()
+	if recipient != nil {
+		span.AddEvent("Encrypt signature")
+		if output, err = signer.Encrypt(signed.Signature, recipient); err != nil {
+			e = fmt.Errorf("failed to encrypt signature: %s", err)
+			log.Errorf("%s", e)
+			span.SetStatus(codes.Error, e.Error())
+			span.RecordError(err)
+			record.Status = store.StatusFailed
+			saveRecord(c, record)
+			releaseNonce(c, nonce)
+			response.ErrorResponse(c, response.NewError(response.KindSigning, response.ErrCodeSigningFailed, e))
+			return
+		}
+	}
+
+	// the record keeps a salted hash of the signature only, a jwt signature carries the unsalted digest of the answers
+	if record.SignatureHash, err = hasher.HashSecret(record.AnswersSalt, signed.Signature); err != nil {
+		log.Errorf("error while hashing signature: %s", err.Error())
+	}
+	record.Status = store.StatusSigned
+	saveRecord(c, record)
 
+	// the gin context is recycled once the handler returns, the subroutine works on a copy
+	cCp := c.Copy()
 	concurrency.GlobalWaitGroup.Add(1)
 	go func() {
 		defer log.Debugf("onboarding subroutine proccess finished")
 		defer concurrency.GlobalWaitGroup.Done()
-		_, span = tracer.Start(ctx, "On boarding subroutine process")
+		_, span := tracer.Start(ctx, "On boarding subroutine process")
 		defer span.End()
 		log.Debugf("start doing things")
 		span.AddEvent("we do some stuff here")
+		record.Status = store.StatusCompleted
+		saveRecord(cCp, record)
 	}()
 
-	response.RegistrationHtmlResponse(c, configuration.HtmlJwtValidationSuccessPage, "", "successfully", testSignature)
+	if response.WantsJSON(c) {
+		response.SuccessResponse(c, model.AnswersSignature{
+			Status:      "successfully",
+			Signature:   output,
+			KeyId:       signed.KeyId,
+			Algorithm:   signed.Algorithm,
+			Claims:      answersClaims(signed.Claims),
+			Encrypted:   recipient != nil,
+			Disclosures: signed.Disclosures,
+			Proofs:      answerProofs(signed.Proofs),
+		})
+		return
+	}
+	response.RegistrationHtmlResponse(c, configuration.HtmlJwtValidationSuccessPage, "", "successfully", output)
+}
 
+// parseRecipient parses the JWK of the recipient the signature is encrypted to.
+//
+// Parameters:
+//   - jwk json.RawMessage: The JWK posted in the request, may be empty
+//
+// Returns:
+//   - *signer.Recipient: The recipient, nil when no JWK was posted
+//   - error: An error if the JWK is not a usable encryption key
+func parseRecipient(jwk json.RawMessage) (*signer.Recipient, error) {
+	if len(jwk) == 0 || string(jwk) == "null" {
+		return nil, nil
+	}
+	return signer.ParseRecipient(jwk)
+}
+
+// checkFormat checks that a requested format is one of the formats the signer produces, an empty format picks the
+// default.
+//
+// Parameters:
+//   - format string: The requested format
+//   - formats []string: The formats the signer produces, signer.SignatureFormats or signer.TokenFormats
+//
+// Returns:
+//   - error: An error if the format is not one of formats
+func checkFormat(format string, formats []string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid parameter: format must be one of %s", strings.Join(formats, ", "))
+}
+
+// validateAnswers checks the answers against the questionnaire referenced by the claims, or the default questionnaire.
+// Answers are only checked to be well formed when no questionnaire is defined.
+//
+// Parameters:
+//   - claims jwt.MapClaims: Claims of the presented JWT
+//   - sub submission: The submitted answers
+//
+// Returns:
+//   - string: Id of the questionnaire the answers were validated against, empty when none
+//   - error: A *questionnaire.ValidationError listing the rejected fields
+func validateAnswers(claims jwt.MapClaims, sub submission) (string, error) {
+	registry := questionnaire.Default()
+	if registry == nil || !registry.Enabled() {
+		return "", sub.validate(nil)
+	}
+	id, _ := claims[configuration.AppConfig().QuestionnaireClaim].(string)
+	q, ok := registry.Lookup(id)
+	if !ok {
+		return id, &questionnaire.ValidationError{Questionnaire: id, Fields: []model.FieldError{{
+			Field:   "jwt",
+			Code:    questionnaire.CodeUnknownQuestionnaire,
+			Message: fmt.Sprintf("questionnaire %q is not defined", id),
+		}}}
+	}
+	return q.Id, sub.validate(q)
 }
 
 // SignAnswers signs the provided answers based on the given questions.
 //
 // Parameters:
 //   - c *gin.Context: Gin context for logging purposes
+//   - subject string: Subject of the presented JWT the answers are bound to
 //   - questions []string: List of questions for which answers are provided
 //   - answers []string: List of answers corresponding to the questions
+//   - format string: Token format of the signature, one of signer.TokenFormats
 //
 // Returns:
-//   - string: The generated signature for the provided answers
+//   - *signer.SignedAnswers: The compact JWS, or the PASETO, over the canonical encoding of the question/answer pairs, with its claims,
+//     key id and algorithm
 //   - error: An error, if any, encountered during the signing process
-func SignAnswers(c *gin.Context, questions, answers []string) (string, error) {
+func SignAnswers(c *gin.Context, subject string, questions, answers []string, format string) (*signer.SignedAnswers, error) {
 	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "routine", "action", "doSignature")
 	defer log.Debugf("sign answer proccess finished")
 	concurrency.GlobalWaitGroup.Add(1)
 	defer concurrency.GlobalWaitGroup.Done()
-	// Implement logic for signing answers here
-	log.Debugf("question:%s, answer:%s", questions, answers)
-	// This is just a placeholder, replace it with your actual signing logic
-	return "test-signature-JonnyBoy", nil
+	log.Debugf("question:%s, answer:%s", questions, privacy.Redact(answers))
+	return signer.Sign(subject, questions, answers, format)
+}
+
+// answerProofs maps the inclusion proofs of a Merkle signature to their API model, nil for other signatures.
+func answerProofs(proofs []signer.InclusionProof) []model.AnswerProof {
+	if proofs == nil {
+		return nil
+	}
+	result := make([]model.AnswerProof, len(proofs))
+	for i, p := range proofs {
+		result[i] = model.AnswerProof{Index: p.Index, Leaf: p.Leaf, Path: p.Path}
+	}
+	return result
+}
+
+// answersClaims maps the claims of an answers signature to their API model.
+func answersClaims(claims signer.AnswersClaims) model.AnswersClaims {
+	c := model.AnswersClaims{
+		Id:               claims.Id,
+		Subject:          claims.Subject,
+		Issuer:           claims.Issuer,
+		IssuedAt:         claims.IssuedAt,
+		AnswersDigest:    claims.AnswersDigest,
+		AnswersDigestAlg: claims.AnswersDigestAlg,
+		AnswersFormat:    claims.AnswersFormat,
+		AnswersRoot:      claims.AnswersRoot,
+		AnswersRootAlg:   claims.AnswersRootAlg,
+		AnswersCount:     claims.AnswersCount,
+	}
+	if len(claims.Answers) > 0 {
+		c.SdAlg = claims.SdAlg
+		c.Answers = make([]model.DisclosureDigest, len(claims.Answers))
+		for i, d := range claims.Answers {
+			c.Answers[i] = model.DisclosureDigest{Digest: d.Digest}
+		}
+	}
+	return c
+}
+
+// saveRecord persists the new state of an onboarding record, failures are logged only since the outcome for the
+// user does not depend on them.
+//
+// Parameters:
+//   - c *gin.Context: Gin context for logging purposes
+//   - record *store.Record: The record to save
+func saveRecord(c *gin.Context, record *store.Record) {
+	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "handlers", "action", "saveRecord")
+	if err := store.Default().Save(record); err != nil {
+		log.Errorf("error while storing onboarding record with status %s: %s", record.Status, err.Error())
+	}
+}
+
+// releaseNonce forgets a claimed token nonce so that a submission failing on our side can be retried.
+//
+// Parameters:
+//   - c *gin.Context: Gin context for logging purposes
+//   - nonce string: The nonce claimed for the token
+func releaseNonce(c *gin.Context, nonce string) {
+	nonces := replay.Default()
+	if nonces == nil {
+		return
+	}
+	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "handlers", "action", "releaseNonce")
+	if err := nonces.Release(nonce); err != nil {
+		log.Errorf("error while releasing token nonce %s: %s", nonce, err.Error())
+	}
+}
+
+// tokenFailure maps a token verification error to the failure reported to the client.
+//
+// Parameters:
+//   - err error: The error returned by the token verifier
+//   - e error: The error reported to the client
+//
+// Returns:
+//   - *response.Error: An expiry, revocation or authorization failure
+func tokenFailure(err, e error) *response.Error {
+	code := tokenErrorCode(err)
+	switch code {
+	case token.ErrCodeExpired:
+		return response.NewError(response.KindExpired, string(code), e)
+	case token.ErrCodeRevoked:
+		return response.NewError(response.KindRevoked, string(code), e)
+	default:
+		return response.NewError(response.KindUnauthorized, string(code), e)
+	}
+}
+
+// tokenErrorCode returns the token error code carried by err, or ErrCodeUnverifiable for foreign errors.
+func tokenErrorCode(err error) token.ErrorCode {
+	var te *token.Error
+	if errors.As(err, &te) {
+		return te.Code
+	}
+	return token.ErrCodeUnverifiable
 }
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/api/response"
+	"jwt-sign/configuration"
+	"jwt-sign/model"
+	"jwt-sign/privacy"
+	"jwt-sign/questionnaire"
+	"jwt-sign/signer"
+	"jwt-sign/store"
+	"jwt-sign/token"
+)
+
+func signedToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return raw
+}
+
+func TestValidateJwtRejectsReplayWithinLeeway(t *testing.T) {
+	for _, jti := range []string{"", "replayed-jti"} {
+		t.Run("jti="+jti, func(t *testing.T) {
+			router := setupRouter(t, func(conf *configuration.Configuration) {
+				conf.JwtLeewaySec = 60
+			})
+			router.POST("/v1/validate-jwt", ValidateJwt)
+
+			// the token expired 5 seconds ago but is still accepted thanks to the leeway
+			claims := jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(-5 * time.Second).Unix()}
+			if jti != "" {
+				claims["jti"] = jti
+			}
+			request := model.JwtValidation{
+				Jwt:       signedToken(t, claims),
+				Questions: []string{"question1"},
+				Answers:   []string{"answer1"},
+			}
+
+			const submissions = 8
+			statuses := make(chan int, submissions)
+			var wg sync.WaitGroup
+			for i := 0; i < submissions; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					status, _ := postJSON(router, "/v1/validate-jwt", request)
+					statuses <- status
+				}()
+			}
+			wg.Wait()
+			close(statuses)
+
+			counts := map[int]int{}
+			for status := range statuses {
+				counts[status]++
+			}
+			assert.Equal(t, map[int]int{http.StatusOK: 1, http.StatusConflict: submissions - 1}, counts)
+
+			// the nonce is still known once the concurrent submissions are over
+			status, _ := postJSON(router, "/v1/validate-jwt", request)
+			assert.Equal(t, http.StatusConflict, status)
+		})
+	}
+}
+
+func TestValidateJwtRejectsTokenExpiredPastLeeway(t *testing.T) {
+	router := setupRouter(t, func(conf *configuration.Configuration) {
+		conf.JwtLeewaySec = 60
+	})
+	router.POST("/v1/validate-jwt", ValidateJwt)
+
+	request := model.JwtValidation{
+		Jwt:       signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(-61 * time.Second).Unix()}),
+		Questions: []string{"question1"},
+		Answers:   []string{"answer1"},
+	}
+	status, _ := postJSON(router, "/v1/validate-jwt", request)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestValidateJwtReportsTokenErrors(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/validate-jwt", ValidateJwt)
+
+	valid := signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(time.Minute).Unix()})
+	parts := strings.Split(valid, ".")
+	for name, test := range map[string]struct {
+		jwt  string
+		code token.ErrorCode
+	}{
+		"malformed": {"JonnyBoy", token.ErrCodeMalformed},
+		"unsigned":  {parts[0] + "." + parts[1] + ".", token.ErrCodeUnsigned},
+		"tampered":  {parts[0] + "." + parts[1] + "." + parts[2][:len(parts[2])-4] + "AAAA", token.ErrCodeSignatureInvalid},
+		"expired":   {signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(-time.Minute).Unix()}), token.ErrCodeExpired},
+	} {
+		status, body := postJSON(router, "/v1/validate-jwt", model.JwtValidation{
+			Jwt:       test.jwt,
+			Questions: []string{"question1"},
+			Answers:   []string{"answer1"},
+		})
+		assert.Equal(t, http.StatusUnauthorized, status, name)
+		var result struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(body, &result), name)
+		assert.Equal(t, string(test.code), result.Data["errorCode"], name)
+	}
+}
+
+func TestValidateJwtRendersExpiredPage(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/validate-jwt", ValidateJwt)
+
+	body, err := json.Marshal(model.JwtValidation{
+		Jwt:       signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(-time.Minute).Unix()}),
+		Questions: []string{"question1"},
+		Answers:   []string{"answer1"},
+	})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/v1/validate-jwt", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// browsers are told the token expired rather than that it is invalid
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Expired token")
+}
+
+func TestValidateJwtStoresNoAnswersDigest(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/validate-jwt", ValidateJwt)
+
+	questions := []string{"Do you agree?", "Country"}
+	claims := jwt.MapClaims{"sub": "JonnyBoy", "jti": "record-jti", "exp": time.Now().Add(time.Minute).Unix()}
+	status, body := postJSON(router, "/v1/validate-jwt", model.JwtValidation{
+		Jwt:       signedToken(t, claims),
+		Questions: questions,
+		Answers:   []string{"yes", "RO"},
+	})
+	require.Equal(t, http.StatusOK, status, string(body))
+	var result struct {
+		Data model.AnswersSignature `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &result))
+
+	records, err := store.Default().FindByTokenId("record-jti")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	stored, err := json.Marshal(records[0])
+	require.NoError(t, err)
+
+	// the record tells the signature it was given, without keeping it
+	assert.NotContains(t, string(stored), result.Data.Signature)
+	hash, err := privacy.Default().HashSecret(records[0].AnswersSalt, result.Data.Signature)
+	require.NoError(t, err)
+	assert.Equal(t, hash, records[0].SignatureHash)
+
+	// a dictionary of candidate answers matches nothing the record keeps along with the questions
+	for _, agree := range []string{"yes", "no"} {
+		for _, country := range []string{"DE", "FR", "RO"} {
+			digest, err := signer.Digest(questions, []string{agree, country})
+			require.NoError(t, err)
+			assert.NotContains(t, string(stored), digest, agree+" "+country)
+		}
+	}
+}
+
+func TestValidateJwtReportsCountMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "questionnaires.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+questionnaires:
+  - id: onboarding
+    questions:
+      - id: country
+        text: Country
+`), 0600))
+
+	for name, configure := range map[string]func(conf *configuration.Configuration){
+		"without questionnaire": nil,
+		"with questionnaire": func(conf *configuration.Configuration) {
+			conf.QuestionnairesFile = path
+			conf.QuestionnaireDefault = "onboarding"
+		},
+	} {
+		router := setupRouter(t, configure)
+		router.POST("/v1/validate-jwt", ValidateJwt)
+
+		status, body := postJSON(router, "/v1/validate-jwt", model.JwtValidation{
+			Jwt:       signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(time.Minute).Unix()}),
+			Questions: []string{"Country", "Age"},
+			Answers:   []string{"RO"},
+		})
+		require.Equal(t, http.StatusBadRequest, status, name)
+		var result struct {
+			Data model.ValidationFailure `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(body, &result), name)
+		assert.Equal(t, response.ErrCodeAnswersInvalid, result.Data.ErrorCode, name)
+		require.Len(t, result.Data.Fields, 1, name)
+		assert.Equal(t, "answers", result.Data.Fields[0].Field, name)
+		assert.Equal(t, questionnaire.CodeCountMismatch, result.Data.Fields[0].Code, name)
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	for _, format := range append([]string{""}, signer.SignatureFormats...) {
+		assert.NoError(t, checkFormat(format, signer.SignatureFormats), format)
+	}
+	for _, format := range []string{"v3.public", "JWT", "v4.secret"} {
+		assert.EqualError(t, checkFormat(format, signer.SignatureFormats),
+			"invalid parameter: format must be one of jwt, v4.public, v4.local, sd-jwt, merkle", format)
+	}
+	assert.Error(t, checkFormat(signer.TokenFormatSdJwt, signer.TokenFormats))
+}
+
+func TestValidateJwtRejectsUnknownFormat(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/validate-jwt", ValidateJwt)
+
+	status, body := postJSON(router, "/v1/validate-jwt", model.JwtValidation{
+		Jwt:       signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(time.Minute).Unix()}),
+		Questions: []string{"question1"},
+		Answers:   []string{"answer1"},
+		Format:    "xml",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &result))
+	assert.Equal(t, response.ErrCodeInvalidRequest, result.Data["errorCode"])
+}
+
+func TestValidateJwtNamespacesNoncesByIssuer(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/validate-jwt", ValidateJwt)
+
+	request := func(issuer string) model.JwtValidation {
+		return model.JwtValidation{
+			Jwt: signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "iss": issuer, "jti": "shared-jti",
+				"exp": time.Now().Add(time.Minute).Unix()}),
+			Questions: []string{"question1"},
+			Answers:   []string{"answer1"},
+		}
+	}
+	// issuers pick their jtis independently, the token of one does not use up the token of the other
+	for _, issuer := range []string{"https://idp.example", "https://other.example"} {
+		status, body := postJSON(router, "/v1/validate-jwt", request(issuer))
+		assert.Equal(t, http.StatusOK, status, "%s: %s", issuer, body)
+	}
+	status, _ := postJSON(router, "/v1/validate-jwt", request("https://idp.example"))
+	assert.Equal(t, http.StatusConflict, status)
+}
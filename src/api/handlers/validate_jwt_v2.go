@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"jwt-sign/api/response"
+	"jwt-sign/model"
+	"jwt-sign/privacy"
+	"jwt-sign/questionnaire"
+	"jwt-sign/signer"
+)
+
+// ValidateJwtV2 godoc
+// @Summary Validate jwt with structured answers
+// @Description Validate Jwt and sign typed answers keyed by question id
+// @ID  validateJwtV2
+// @Produce html,json
+// @Param model.JwtValidationV2 body model.JwtValidationV2 true "validate signature"
+// @Param lang query string false "Locale of the html page, takes precedence over the Accept-Language header"
+// @Success 200 {object} model.JSONSuccessResult{data=model.AnswersSignature} "The request was validated and has been processed successfully (sync). The html page is rendered unless the Accept header prefers application/json"
+// @Failure 400 {object} model.JSONFailureResult{data=model.ValidationFailure} "The payload is invalid or the answers do not match the questionnaire"
+// @Failure 401 {object} model.JSONFailureResult "The jwt is malformed, unsigned, expired, revoked, its signature is invalid or its claims are not accepted"
+// @Failure 409 {object} model.JSONFailureResult "The jwt was already used"
+// @Failure 500 {object} model.JSONFailureResult "The answers could not be signed, or the token nonce or the onboarding record could not be stored"
+// @Router /v2/validate-jwt [post]
+func ValidateJwtV2(c *gin.Context) {
+	concurrency.GlobalWaitGroup.Add(1)
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "handlers", "action", "ValidateJwtV2")
+
+	var (
+		err           error
+		e             error
+		rr            model.JwtValidationV2
+		ctx           = c.Request.Context()
+		correlationId = c.MustGet("correlation_id").(string)
+	)
+
+	ctx, span := tracer.Start(ctx, "JWT user validation",
+		oteltrace.WithAttributes(attribute.String("CorrelationId", correlationId)))
+	defer span.End()
+
+	// validate params
+	if err = c.ShouldBindJSON(&rr); err != nil {
+		e = fmt.Errorf("error while parsing request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+	if err = rr.Validate(); err != nil {
+		e = fmt.Errorf("error while validating request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+	if err = checkFormat(rr.Format, signer.SignatureFormats); err != nil {
+		e = fmt.Errorf("error while validating request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+
+	recipient, err := parseRecipient(rr.EncryptTo)
+	if err != nil {
+		e = fmt.Errorf("error while validating request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+
+	onboard(c, ctx, span, log, rr.Jwt, &structuredSubmission{answers: rr.Answers}, rr.Format, recipient)
+}
+
+// structuredSubmission holds typed answers keyed by question id.
+type structuredSubmission struct {
+	answers []model.AnswerItem
+}
+
+func (s *structuredSubmission) validate(q *questionnaire.Questionnaire) error {
+	if q == nil {
+		return nil
+	}
+	return q.ValidateStructured(s.answers)
+}
+
+func (s *structuredSubmission) sign(c *gin.Context, subject, format string) (*signer.SignedAnswers, error) {
+	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "routine", "action", "doSignature")
+	defer log.Debugf("sign answer proccess finished")
+	concurrency.GlobalWaitGroup.Add(1)
+	defer concurrency.GlobalWaitGroup.Done()
+	answers := make([]signer.Answer, len(s.answers))
+	ids := make([]string, len(s.answers))
+	for i, item := range s.answers {
+		answers[i] = signer.Answer{QuestionId: item.QuestionId, Value: item.Answer.Value}
+		ids[i] = item.QuestionId
+	}
+	_, values := s.record()
+	log.Debugf("question:%s, answer:%s", ids, privacy.Redact(values))
+	return signer.SignStructured(subject, answers, format)
+}
+
+// record returns the question ids along with the answers, typed answers are stored in their JSON encoding.
+func (s *structuredSubmission) record() ([]string, []string) {
+	questions := make([]string, len(s.answers))
+	answers := make([]string, len(s.answers))
+	for i, item := range s.answers {
+		questions[i] = item.QuestionId
+		if v, ok := item.Answer.Value.(string); ok {
+			answers[i] = v
+			continue
+		}
+		encoded, _ := json.Marshal(item.Answer.Value)
+		answers[i] = string(encoded)
+	}
+	return questions, answers
+}
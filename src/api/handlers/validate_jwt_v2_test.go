@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/api/response"
+	"jwt-sign/configuration"
+	"jwt-sign/model"
+	"jwt-sign/questionnaire"
+	"jwt-sign/signer"
+)
+
+// structuredAnswers builds the typed answers of a request from question id and value pairs.
+func structuredAnswers(items ...interface{}) []model.AnswerItem {
+	answers := make([]model.AnswerItem, 0, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		answers = append(answers, model.AnswerItem{QuestionId: items[i].(string), Answer: model.AnswerValue{Value: items[i+1]}})
+	}
+	return answers
+}
+
+func TestValidateJwtV2(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v2/validate-jwt", ValidateJwtV2)
+
+	status, body := postJSON(router, "/v2/validate-jwt", model.JwtValidationV2{
+		Jwt:     signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(time.Minute).Unix()}),
+		Answers: structuredAnswers("country", "RO", "age", 42.0, "consent", true, "languages", []string{"de", "ro"}),
+	})
+	require.Equal(t, http.StatusOK, status, string(body))
+	var result struct {
+		Data model.AnswersSignature `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &result))
+
+	// the signature binds the digest of the typed answers, whatever their order
+	verification, err := signer.Verify(result.Data.Signature)
+	require.NoError(t, err)
+	digest, err := signer.DigestStructured([]signer.Answer{
+		{QuestionId: "age", Value: 42.0},
+		{QuestionId: "consent", Value: true},
+		{QuestionId: "country", Value: "RO"},
+		{QuestionId: "languages", Value: []string{"de", "ro"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "JonnyBoy", verification.Claims.Subject)
+	assert.Equal(t, signer.FormatStructured, verification.Claims.AnswersFormat)
+	assert.Equal(t, digest, verification.Claims.AnswersDigest)
+}
+
+func TestValidateJwtV2RejectsInvalidRequests(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v2/validate-jwt", ValidateJwtV2)
+
+	for name, body := range map[string]interface{}{
+		"missing jwt":     model.JwtValidationV2{Answers: structuredAnswers("country", "RO")},
+		"missing answers": model.JwtValidationV2{Jwt: "jwt"},
+		"answered twice":  model.JwtValidationV2{Jwt: "jwt", Answers: structuredAnswers("country", "RO", "country", "DE")},
+		"unknown format":  model.JwtValidationV2{Jwt: "jwt", Answers: structuredAnswers("country", "RO"), Format: "xml"},
+		"missing answer":  map[string]interface{}{"jwt": "jwt", "answers": []interface{}{map[string]interface{}{"questionId": "country"}}},
+		"object answer":   map[string]interface{}{"jwt": "jwt", "answers": []interface{}{map[string]interface{}{"questionId": "country", "answer": map[string]string{"a": "b"}}}},
+		"mixed choices":   map[string]interface{}{"jwt": "jwt", "answers": []interface{}{map[string]interface{}{"questionId": "languages", "answer": []interface{}{"de", 1}}}},
+	} {
+		status, _ := postJSON(router, "/v2/validate-jwt", body)
+		assert.Equal(t, http.StatusBadRequest, status, name)
+	}
+}
+
+func TestValidateJwtV2ValidatesAnswers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "questionnaires.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+questionnaires:
+  - id: onboarding
+    questions:
+      - id: country
+        type: choice
+        allowed: [DE, FR, RO]
+        required: true
+      - id: age
+        type: integer
+`), 0600))
+	router := setupRouter(t, func(conf *configuration.Configuration) {
+		conf.QuestionnairesFile = path
+		conf.QuestionnaireDefault = "onboarding"
+	})
+	router.POST("/v2/validate-jwt", ValidateJwtV2)
+
+	status, body := postJSON(router, "/v2/validate-jwt", model.JwtValidationV2{
+		Jwt:     signedToken(t, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(time.Minute).Unix()}),
+		Answers: structuredAnswers("country", "IT", "age", 4.2),
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+	var result struct {
+		Data model.ValidationFailure `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &result))
+	assert.Equal(t, response.ErrCodeAnswersInvalid, result.Data.ErrorCode)
+	require.Len(t, result.Data.Fields, 2)
+	assert.Equal(t, "answers[0].answer", result.Data.Fields[0].Field)
+	assert.Equal(t, questionnaire.CodeNotAllowed, result.Data.Fields[0].Code)
+	assert.Equal(t, "answers[1].answer", result.Data.Fields[1].Field)
+	assert.Equal(t, questionnaire.CodeInvalidType, result.Data.Fields[1].Code)
+}
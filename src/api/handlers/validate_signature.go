@@ -1,10 +1,10 @@
 package handlers
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"github.com/danbordeanu/go-logger"
 	"github.com/danbordeanu/go-stats/concurrency"
-	"github.com/danbordeanu/go-utils"
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -12,18 +12,20 @@ import (
 	"jwt-sign/api/response"
 	"jwt-sign/configuration"
 	"jwt-sign/model"
-	"strings"
+	"jwt-sign/privacy"
+	"jwt-sign/signer"
 )
 
 // VerifySignature godoc
 // @Summary Verify signature
-// @Description Verify signature for a given user
+// @Description Verify the signature cryptographically and check it was issued to the given user. When a proof is posted, the answer it holds is checked against the Merkle root of the signature
 // @ID verifySignature
 // @Accept json
-// @Produce html
+// @Produce html,json
 // @Param model.SignatureValidation body model.SignatureValidation true "validate signature"
-// @Success 200 {object} model.JSONSuccessResult "The request was validated and has been processed successfully (sync)"
-// @Failure 400 {object} model.JSONFailureResult "The payload is invalid"
+// @Param lang query string false "Locale of the html page, takes precedence over the Accept-Language header"
+// @Success 200 {object} model.JSONSuccessResult{data=model.SignatureVerification} "The request was validated and has been processed successfully (sync). The html page is rendered unless the Accept header prefers application/json"
+// @Failure 400 {object} model.JSONFailureResult "The payload is invalid, the signature does not verify or the proof does not lead to its Merkle root"
 // @Router /v1/verify-signature [post]
 func VerifySignature(c *gin.Context) {
 	concurrency.GlobalWaitGroup.Add(1)
@@ -46,14 +48,14 @@ func VerifySignature(c *gin.Context) {
 		e = fmt.Errorf("error while parsing request: %s", err.Error())
 		span.SetStatus(codes.Error, e.Error())
 		span.RecordError(err)
-		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
 		return
 	}
 	if err = rr.Validate(); err != nil {
 		e = fmt.Errorf("error while validating request: %s", err.Error())
 		span.SetStatus(codes.Error, e.Error())
 		span.RecordError(err)
-		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
 		return
 	}
 
@@ -61,38 +63,74 @@ func VerifySignature(c *gin.Context) {
 	user := rr.User
 	signature := rr.Signature
 
-	log.Debugf("user:%s, signature:%s", user, signature)
+	log.Debugf("user:%s, signature:%s", user, privacy.Fingerprint(signature))
 
 	span.AddEvent("Validate signature")
-	// Perform signature verification logic here
-	// Check if the user is present in the signature
-	if !ValidateUserSignature(c, signature, user) {
-		e = fmt.Errorf("user is not present in the signature")
+	// Verify the signature against the issuing key and check it was issued to the user
+	verification, err := ValidateUserSignature(c, signature, user)
+	if err != nil {
+		e = fmt.Errorf("signature verification failed: %s", err.Error())
 		span.SetStatus(codes.Error, e.Error())
 		span.RecordError(err)
-		response.FailureResponse(c, nil, utils.HttpError{Code: 400, Err: e})
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeSignatureInvalid, e))
 		return
 	}
+	span.SetAttributes(attribute.String("signature.kid", verification.KeyId), attribute.String("signature.alg", verification.Algorithm))
 
-	// Assuming a successful verification, construct the response
-	response.RegistrationHtmlResponse(c, configuration.HtmlJwtValidationSuccessPage, "", "OK if signature belongs to user,", "")
+	// a single answer is verified against the Merkle root of the signature
+	var disclosed *model.DisclosedAnswer
+	if rr.Proof != nil {
+		span.AddEvent("Validate answer proof")
+		answer, err := signer.VerifyInclusion(verification.Claims, signer.InclusionProof{Index: rr.Proof.Index, Leaf: rr.Proof.Leaf, Path: rr.Proof.Path})
+		if err != nil {
+			e = fmt.Errorf("answer proof verification failed: %s", err.Error())
+			log.Debugf("%s", e)
+			span.SetStatus(codes.Error, e.Error())
+			span.RecordError(err)
+			response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeSignatureInvalid, e))
+			return
+		}
+		a := disclosedAnswer(*answer)
+		disclosed = &a
+	}
+
+	if response.WantsJSON(c) {
+		response.SuccessResponse(c, model.SignatureVerification{
+			Status:    "successfully",
+			User:      user,
+			KeyId:     verification.KeyId,
+			Algorithm: verification.Algorithm,
+			Claims:    answersClaims(verification.Claims),
+			Disclosed: disclosed,
+		})
+		return
+	}
+	response.SignatureHtmlResponse(c, configuration.HtmlJwtValidationSuccessPage, "successfully", verification.KeyId, verification.Algorithm)
 
 }
 
-// ValidateUserSignature validates if the given user is present in the provided signature.
+// ValidateUserSignature verifies the signature cryptographically and checks that it was issued to the given user.
 //
 // Parameters:
 //   - c *gin.Context: Gin context for logging purposes
 //   - signature string: The signature to be validated
-//   - user string: The user to check within the signature
+//   - user string: The user the signature must have been issued to
 //
 // Returns:
-//   - bool: True if the user is present in the signature, otherwise false
-func ValidateUserSignature(c *gin.Context, signature, user string) bool {
+//   - *signer.Verification: The key id and algorithm that verified the signature
+//   - error: An error if the signature does not verify or was issued to another user
+func ValidateUserSignature(c *gin.Context, signature, user string) (*signer.Verification, error) {
 	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "routine", "action", "doValidate")
 	defer log.Debugf("validate proccess finished")
 	concurrency.GlobalWaitGroup.Add(1)
 	defer concurrency.GlobalWaitGroup.Done()
-	log.Debugf("signature:%s, user:%s", signature, user)
-	return strings.Contains(signature, user)
+	log.Debugf("signature:%s, user:%s", privacy.Fingerprint(signature), user)
+	verification, err := signer.Verify(signature)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(verification.Claims.Subject), []byte(user)) != 1 {
+		return nil, fmt.Errorf("signature was not issued to user")
+	}
+	return verification, nil
 }
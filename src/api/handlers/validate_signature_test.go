@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/model"
+	"jwt-sign/signer"
+)
+
+// signatureVerification decodes the data of a successful signature verification.
+func signatureVerification(t *testing.T, body []byte) model.SignatureVerification {
+	t.Helper()
+	var result struct {
+		Data model.SignatureVerification `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &result))
+	return result.Data
+}
+
+func TestVerifySignature(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/verify-signature", VerifySignature)
+
+	signed, err := signer.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, signer.TokenFormatJwt)
+	require.NoError(t, err)
+
+	status, body := postJSON(router, "/v1/verify-signature", model.SignatureValidation{User: "JonnyBoy", Signature: signed.Signature})
+	require.Equal(t, http.StatusOK, status, string(body))
+	verification := signatureVerification(t, body)
+	assert.Equal(t, "successfully", verification.Status)
+	assert.Equal(t, "JonnyBoy", verification.User)
+	assert.Equal(t, signed.KeyId, verification.KeyId)
+	assert.Equal(t, signed.Algorithm, verification.Algorithm)
+	assert.Nil(t, verification.Disclosed)
+
+	// the html page is rendered unless JSON is asked for
+	req := httptest.NewRequest(http.MethodPost, "/v1/verify-signature",
+		strings.NewReader(`{"user":"JonnyBoy","signature":"`+signed.Signature+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+}
+
+func TestVerifySignatureRejectsOtherUsers(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/verify-signature", VerifySignature)
+
+	signed, err := signer.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, signer.TokenFormatJwt)
+	require.NoError(t, err)
+	parts := strings.Split(signed.Signature, ".")
+	parts[2] = parts[2][:len(parts[2])-4] + "AAAA"
+
+	for name, request := range map[string]model.SignatureValidation{
+		"other user":        {User: "Jonny", Signature: signed.Signature},
+		"user as prefix":    {User: "JonnyBoyz", Signature: signed.Signature},
+		"tampered":          {User: "JonnyBoy", Signature: strings.Join(parts, ".")},
+		"contains the user": {User: "JonnyBoy", Signature: "JonnyBoy." + signed.Signature},
+		"not a signature":   {User: "JonnyBoy", Signature: "JonnyBoy"},
+		"missing user":      {Signature: signed.Signature},
+		"missing signature": {User: "JonnyBoy"},
+	} {
+		status, _ := postJSON(router, "/v1/verify-signature", request)
+		assert.Equal(t, http.StatusBadRequest, status, name)
+	}
+}
+
+func TestVerifySignatureWithProof(t *testing.T) {
+	router := setupRouter(t, nil)
+	router.POST("/v1/verify-signature", VerifySignature)
+
+	signed, err := signer.Sign("JonnyBoy", []string{"question1", "question2", "question3"}, []string{"answer1", "answer2", "answer3"}, signer.TokenFormatMerkle)
+	require.NoError(t, err)
+	proof := func(p signer.InclusionProof) *model.AnswerProof {
+		return &model.AnswerProof{Index: p.Index, Leaf: p.Leaf, Path: p.Path}
+	}
+
+	status, body := postJSON(router, "/v1/verify-signature", model.SignatureValidation{
+		User:      "JonnyBoy",
+		Signature: signed.Signature,
+		Proof:     proof(signed.Proofs[1]),
+	})
+	require.Equal(t, http.StatusOK, status, string(body))
+	verification := signatureVerification(t, body)
+	require.NotNil(t, verification.Disclosed)
+	assert.Equal(t, "question2", verification.Disclosed.Question)
+	assert.Equal(t, "answer2", verification.Disclosed.Answer.Value)
+
+	// the proof of another leaf, or of another signature
+	other, err := signer.Sign("JonnyBoy", []string{"question1", "question2", "question3"}, []string{"answer1", "answer2", "answer3"}, signer.TokenFormatMerkle)
+	require.NoError(t, err)
+	wrongIndex := proof(signed.Proofs[1])
+	wrongIndex.Index = 0
+	for name, p := range map[string]*model.AnswerProof{
+		"other index":     wrongIndex,
+		"other signature": proof(other.Proofs[1]),
+		"out of range":    {Index: 3, Leaf: signed.Proofs[0].Leaf, Path: signed.Proofs[0].Path},
+		"negative index":  {Index: -1, Leaf: signed.Proofs[0].Leaf, Path: signed.Proofs[0].Path},
+		"missing leaf":    {Index: 0, Path: signed.Proofs[0].Path},
+	} {
+		status, _ = postJSON(router, "/v1/verify-signature", model.SignatureValidation{User: "JonnyBoy", Signature: signed.Signature, Proof: p})
+		assert.Equal(t, http.StatusBadRequest, status, name)
+	}
+}
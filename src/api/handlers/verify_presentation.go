@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"jwt-sign/api/response"
+	"jwt-sign/configuration"
+	"jwt-sign/model"
+	"jwt-sign/privacy"
+	"jwt-sign/signer"
+)
+
+// VerifyPresentation godoc
+// @Summary Verify presentation
+// @Description Verify an SD-JWT presentation of signed answers, carrying any subset of their disclosures, and check it was issued to the given user
+// @ID verifyPresentation
+// @Accept json
+// @Produce html,json
+// @Param model.PresentationValidation body model.PresentationValidation true "verify presentation"
+// @Param lang query string false "Locale of the html page, takes precedence over the Accept-Language header"
+// @Success 200 {object} model.JSONSuccessResult{data=model.PresentationVerification} "The presentation was verified, the disclosed answers are listed. The html page is rendered unless the Accept header prefers application/json"
+// @Failure 400 {object} model.JSONFailureResult "The payload is invalid, the signature does not verify or a disclosure is not listed in it"
+// @Router /v1/verify-presentation [post]
+func VerifyPresentation(c *gin.Context) {
+	concurrency.GlobalWaitGroup.Add(1)
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().WithContextCorrelationId(c).With("package", "handlers", "action", "VerifyPresentation")
+
+	var (
+		e             error
+		err           error
+		rr            model.PresentationValidation
+		ctx           = c.Request.Context()
+		correlationId = c.MustGet("correlation_id").(string)
+	)
+	_, span := tracer.Start(ctx, "Presentation Validation",
+		oteltrace.WithAttributes(attribute.String("CorrelationId", correlationId)))
+	defer span.End()
+
+	// validate params
+	if err = c.ShouldBindJSON(&rr); err != nil {
+		e = fmt.Errorf("error while parsing request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+	if err = rr.Validate(); err != nil {
+		e = fmt.Errorf("error while validating request: %s", err.Error())
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeInvalidRequest, e))
+		return
+	}
+
+	log.Debugf("user:%s, presentation:%s", rr.User, privacy.Fingerprint(rr.Presentation))
+
+	span.AddEvent("Validate presentation")
+	// Verify the issuer signed jwt and the disclosures, then check it was issued to the user
+	presentation, err := signer.VerifyPresentation(rr.Presentation)
+	if err == nil && subtle.ConstantTimeCompare([]byte(presentation.Claims.Subject), []byte(rr.User)) != 1 {
+		err = fmt.Errorf("signature was not issued to user")
+	}
+	if err != nil {
+		e = fmt.Errorf("presentation verification failed: %s", err.Error())
+		log.Debugf("%s", e)
+		span.SetStatus(codes.Error, e.Error())
+		span.RecordError(err)
+		response.ErrorResponse(c, response.NewError(response.KindValidation, response.ErrCodeSignatureInvalid, e))
+		return
+	}
+	span.SetAttributes(attribute.String("signature.kid", presentation.KeyId), attribute.String("signature.alg", presentation.Algorithm),
+		attribute.Int("presentation.disclosed", len(presentation.Disclosed)))
+
+	if response.WantsJSON(c) {
+		disclosed := make([]model.DisclosedAnswer, len(presentation.Disclosed))
+		for i, answer := range presentation.Disclosed {
+			disclosed[i] = disclosedAnswer(answer)
+		}
+		response.SuccessResponse(c, model.PresentationVerification{
+			Status:    "successfully",
+			User:      rr.User,
+			KeyId:     presentation.KeyId,
+			Algorithm: presentation.Algorithm,
+			Claims:    answersClaims(presentation.Claims),
+			Disclosed: disclosed,
+		})
+		return
+	}
+	response.SignatureHtmlResponse(c, configuration.HtmlJwtValidationSuccessPage, "successfully", presentation.KeyId, presentation.Algorithm)
+}
+
+// disclosedAnswer maps an answer disclosed by a presentation or proven by a Merkle proof to its API model.
+func disclosedAnswer(answer signer.DisclosedAnswer) model.DisclosedAnswer {
+	return model.DisclosedAnswer{
+		Question:   answer.Question,
+		QuestionId: answer.QuestionId,
+		Answer:     model.AnswerValue{Value: answer.Value},
+	}
+}
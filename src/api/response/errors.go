@@ -0,0 +1,138 @@
+package response
+
+import (
+	"net/http"
+
+	"github.com/danbordeanu/go-utils"
+	"github.com/gin-gonic/gin"
+	"jwt-sign/configuration"
+	"jwt-sign/i18n"
+	apimodel "jwt-sign/model"
+)
+
+// Kind classifies the failures reported to the client, each kind has its own status code and html page.
+type Kind int
+
+const (
+	// KindValidation the request payload is invalid
+	KindValidation Kind = iota
+	// KindUnauthorized the jwt is malformed, unsigned, badly signed or its claims are not accepted
+	KindUnauthorized
+	// KindExpired the jwt expired
+	KindExpired
+	// KindRevoked the jwt was revoked
+	KindRevoked
+	// KindAlreadyUsed the jwt was already submitted
+	KindAlreadyUsed
+	// KindSigning the answers could not be signed
+	KindSigning
+	// KindInternal any other failure on our side
+	KindInternal
+)
+
+// Error codes of the failures that are not token verification failures
+const (
+	// ErrCodeInvalidRequest the request payload is invalid
+	ErrCodeInvalidRequest = "invalid_request"
+	// ErrCodeAnswersInvalid the answers do not match the questionnaire
+	ErrCodeAnswersInvalid = "answers_invalid"
+	// ErrCodeAlreadyUsed the jwt was already submitted
+	ErrCodeAlreadyUsed = "token_already_used"
+	// ErrCodeSignatureInvalid the answers signature does not verify
+	ErrCodeSignatureInvalid = "signature_invalid"
+	// ErrCodeSigningFailed the answers, or the token, could not be signed
+	ErrCodeSigningFailed = "signing_failed"
+	// ErrCodeIssuanceRejected the token request violates the issuance policy
+	ErrCodeIssuanceRejected = "issuance_rejected"
+	// ErrCodeInternal any other failure on our side
+	ErrCodeInternal = "internal_error"
+)
+
+// Error is a failure reported to the client, with a machine readable code and the rejected fields if any.
+type Error struct {
+	Kind   Kind
+	Code   string
+	Err    error
+	Fields []apimodel.FieldError
+}
+
+// NewError creates an Error.
+//
+// Parameters:
+//   - kind Kind: Kind of the failure
+//   - code string: Machine readable error code returned to API clients
+//   - err error: The underlying error
+//
+// Returns:
+//   - *Error: The error
+func NewError(kind Kind, code string, err error) *Error {
+	return &Error{Kind: kind, Code: code, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status code of the error.
+func (e *Error) Status() int {
+	switch e.Kind {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized, KindExpired, KindRevoked:
+		return http.StatusUnauthorized
+	case KindAlreadyUsed:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Page returns the html page rendering the error.
+func (e *Error) Page() string {
+	switch e.Kind {
+	case KindExpired:
+		return configuration.HtmlJwtExpired
+	case KindAlreadyUsed:
+		return configuration.HtmlJwtAlreadyUsed
+	default:
+		return configuration.HtmlRegistrationFailed
+	}
+}
+
+// ErrorResponse sends the error to the client with its status code, as a JSON failure result when the client prefers
+// JSON and as its html page otherwise.
+//
+// Parameters:
+//   - c *gin.Context: Gin context for handling the response
+//   - err *Error: The error to report
+func ErrorResponse(c *gin.Context, err *Error) {
+	if WantsJSON(c) {
+		ErrorJSONResponse(c, err)
+		return
+	}
+	c.HTML(err.Status(), err.Page(), gin.H{
+		"status":        "failed",
+		"errorCode":     err.Code,
+		"fields":        err.Fields,
+		"correlationId": c.MustGet("correlation_id").(string),
+		"locale":        c.GetString(i18n.ContextKey),
+	})
+}
+
+// ErrorJSONResponse sends the error to the client with its status code as a JSON failure result, whatever the client
+// prefers. It reports the errors of the endpoints that only produce JSON.
+//
+// Parameters:
+//   - c *gin.Context: Gin context for handling the response
+//   - err *Error: The error to report
+func ErrorJSONResponse(c *gin.Context, err *Error) {
+	data := gin.H{"errorCode": err.Code}
+	if len(err.Fields) > 0 {
+		data["fields"] = err.Fields
+	}
+	FailureResponse(c, data, utils.HttpError{Code: err.Status(), Err: err.Err})
+}
@@ -0,0 +1,91 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/configuration"
+	apimodel "jwt-sign/model"
+)
+
+// errorPages renders every error page as its name followed by the error code and the correlation id.
+func errorPages(router *gin.Engine) {
+	tmpl := template.New("")
+	for _, page := range []string{configuration.HtmlJwtExpired, configuration.HtmlJwtAlreadyUsed, configuration.HtmlRegistrationFailed} {
+		template.Must(tmpl.New(page).Parse(page + ` {{.errorCode}} {{.correlationId}}`))
+	}
+	router.SetHTMLTemplate(tmpl)
+}
+
+func TestErrorStatusAndPage(t *testing.T) {
+	for kind, expected := range map[Kind]struct {
+		status int
+		page   string
+	}{
+		KindValidation:   {http.StatusBadRequest, configuration.HtmlRegistrationFailed},
+		KindUnauthorized: {http.StatusUnauthorized, configuration.HtmlRegistrationFailed},
+		KindExpired:      {http.StatusUnauthorized, configuration.HtmlJwtExpired},
+		KindRevoked:      {http.StatusUnauthorized, configuration.HtmlRegistrationFailed},
+		KindAlreadyUsed:  {http.StatusConflict, configuration.HtmlJwtAlreadyUsed},
+		KindSigning:      {http.StatusInternalServerError, configuration.HtmlRegistrationFailed},
+		KindInternal:     {http.StatusInternalServerError, configuration.HtmlRegistrationFailed},
+	} {
+		err := NewError(kind, "code", fmt.Errorf("failure"))
+		assert.Equal(t, expected.status, err.Status(), kind)
+		assert.Equal(t, expected.page, err.Page(), kind)
+	}
+}
+
+func TestErrorResponseJSON(t *testing.T) {
+	cause := fmt.Errorf("failure")
+	err := NewError(KindValidation, ErrCodeAnswersInvalid, cause)
+	err.Fields = []apimodel.FieldError{{Field: "answers[0]", Code: "required"}}
+	assert.ErrorIs(t, err, cause)
+
+	w := serve(func(c *gin.Context) { ErrorResponse(c, err) }, "application/json", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var result struct {
+		Code  int    `json:"code"`
+		Error string `json:"error"`
+		Data  struct {
+			ErrorCode string                `json:"errorCode"`
+			Fields    []apimodel.FieldError `json:"fields"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, http.StatusBadRequest, result.Code)
+	assert.Equal(t, "failure", result.Error)
+	assert.Equal(t, ErrCodeAnswersInvalid, result.Data.ErrorCode)
+	assert.Equal(t, err.Fields, result.Data.Fields)
+
+	// the fields are left out when there are none
+	w = serve(func(c *gin.Context) {
+		ErrorResponse(c, NewError(KindAlreadyUsed, ErrCodeAlreadyUsed, cause))
+	}, "application/json", nil)
+	require.Equal(t, http.StatusConflict, w.Code)
+	var data struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+	assert.Equal(t, map[string]interface{}{"errorCode": ErrCodeAlreadyUsed}, data.Data)
+}
+
+func TestErrorResponseHTML(t *testing.T) {
+	w := serve(func(c *gin.Context) {
+		ErrorResponse(c, NewError(KindExpired, "token_expired", fmt.Errorf("token is expired")))
+	}, "", errorPages)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, configuration.HtmlJwtExpired+" token_expired 0a1b2c", w.Body.String())
+
+	w = serve(func(c *gin.Context) {
+		ErrorResponse(c, NewError(KindInternal, ErrCodeInternal, fmt.Errorf("failure")))
+	}, "text/html", errorPages)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, configuration.HtmlRegistrationFailed+" "+ErrCodeInternal+" 0a1b2c", w.Body.String())
+}
@@ -6,8 +6,11 @@ import (
 	"github.com/danbordeanu/go-utils"
 	"github.com/gin-gonic/gin"
 	"jwt-sign/configuration"
+	"jwt-sign/i18n"
 	"math"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // SuccessResponse sends a successful JSON response to the client.
@@ -52,11 +55,87 @@ func FailureResponse(c *gin.Context, data interface{}, err utils.HttpError) {
 	})
 }
 
+// WantsJSON reports whether the client prefers JSON over HTML according to the q-values of its Accept header. Browsers
+// and clients without a preference, or with the same preference for both, get HTML.
+//
+// Parameters:
+//   - c *gin.Context: Gin context of the request
+//
+// Returns:
+//   - bool: true when the response must be JSON
+func WantsJSON(c *gin.Context) bool {
+	accept := c.GetHeader("Accept")
+	return acceptQuality(accept, gin.MIMEJSON) > acceptQuality(accept, gin.MIMEHTML)
+}
+
+// acceptQuality returns the q-value the Accept header gives to the media type, taken from its most specific matching
+// range as in RFC 9110 section 12.5.1. Ranges without a q-value have a quality of 1, a media type no range matches has
+// a quality of 0.
+//
+// Parameters:
+//   - accept string: Accept header of the request
+//   - mediaType string: Media type, without parameters
+//
+// Returns:
+//   - float64: Quality of the media type, between 0 and 1
+func acceptQuality(accept, mediaType string) float64 {
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+		var rangeSpecificity int
+		switch {
+		case mediaRange == mediaType:
+			rangeSpecificity = 2
+		case mediaRange == "*/*":
+			rangeSpecificity = 0
+		case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+			rangeSpecificity = 1
+		default:
+			continue
+		}
+		if rangeSpecificity < specificity {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || strings.ToLower(strings.TrimSpace(name)) != "q" {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && parsed >= 0 && parsed <= 1 {
+				q = parsed
+			}
+		}
+		quality, specificity = q, rangeSpecificity
+	}
+	return quality
+}
+
 func RegistrationHtmlResponse(c *gin.Context, page, login, status, testSignature string) {
 	PutBody := map[string]interface{}{
 		"login":         login,
 		"status":        status,
 		"testSignature": testSignature, // Add the testSignature field
+		"locale":        c.GetString(i18n.ContextKey),
+	}
+	c.HTML(http.StatusOK, page, gin.H(PutBody))
+}
+
+// SignatureHtmlResponse renders a signature verification page with the key and algorithm that verified it.
+//
+// Parameters:
+//   - c *gin.Context: Gin context for handling the response
+//   - page string: Template to render
+//   - status string: Verification status
+//   - kid string: Id of the key that verified the signature
+//   - alg string: Algorithm that verified the signature
+func SignatureHtmlResponse(c *gin.Context, page, status, kid, alg string) {
+	PutBody := map[string]interface{}{
+		"status": status,
+		"kid":    kid,
+		"alg":    alg,
+		"locale": c.GetString(i18n.ContextKey),
 	}
 	c.HTML(http.StatusOK, page, gin.H(PutBody))
 }
@@ -0,0 +1,71 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve handles a request with the Accept header, when not empty, and returns the recorded response.
+func serve(handler gin.HandlerFunc, accept string, configure func(router *gin.Engine)) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if configure != nil {
+		configure(router)
+	}
+	router.Use(func(c *gin.Context) { c.Set("correlation_id", "0a1b2c") })
+	router.GET("/", handler)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestWantsJSON(t *testing.T) {
+	for accept, expected := range map[string]string{
+		"":                                  "html",
+		"*/*":                               "html",
+		"text/html":                         "html",
+		"application/json":                  "json",
+		"application/json, text/html;q=0.5": "json",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8": "html",
+		"image/png":                                 "html",
+		"text/html;q=0.1, application/json":         "json",
+		"text/html;q=0.5, application/json;q=0.5":   "html",
+		"text/*;q=0.2, application/*":               "json",
+		"*/*;q=0.1, application/json;q=0":           "html",
+		"text/html;q=0, */*":                        "json",
+		"application/json;charset=utf-8;q=0.9":      "json",
+		"text/html; q=0.3, application/json; q=0.7": "json",
+	} {
+		w := serve(func(c *gin.Context) {
+			if WantsJSON(c) {
+				c.String(http.StatusOK, "json")
+			} else {
+				c.String(http.StatusOK, "html")
+			}
+		}, accept, nil)
+		assert.Equal(t, expected, w.Body.String(), accept)
+	}
+}
+
+func TestSuccessResponse(t *testing.T) {
+	w := serve(func(c *gin.Context) {
+		SuccessResponse(c, gin.H{"status": "successfully"})
+	}, "application/json", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, float64(http.StatusOK), result["code"])
+	assert.Equal(t, map[string]interface{}{"status": "successfully"}, result["data"])
+	assert.Equal(t, "0a1b2c", result["correlation_id"])
+}
@@ -32,6 +32,80 @@ type Configuration struct {
 
 	// Cors allow origins
 	CorsAllowOrigins string
+
+	// jwt verification keys
+	JwtHmacSecret          string
+	JwtPublicKeyFile       string
+	JwtJwksUrl             string
+	JwtJwksRefreshSec      int32
+	JwtJwksMinRefetchSec   int32
+	JwtJwksStaleIfErrorSec int32
+
+	// jwt algorithm policy
+	JwtAlgorithms       []string
+	JwtIssuerAlgorithms []string
+
+	// paseto
+	PasetoLocalKey string
+
+	// jwt registered claims policy
+	JwtRequireExp bool
+	JwtRequireNbf bool
+	JwtRequireIat bool
+	JwtRequireSub bool
+	JwtRequireJti bool
+	JwtIssuers    []string
+	JwtAudiences  []string
+	JwtLeewaySec  int32
+
+	// key store
+	KeyStoreDir       string
+	KeyStoreReloadSec int32
+	JwksMaxAgeSec     int32
+
+	// signing key rotation
+	KeyRotationPeriodHours int32
+	KeyRotationGraceHours  int32
+	KeyRotationStateFile   string
+
+	// answers signing
+	SigningAlgorithm        string
+	SigningKeyId            string
+	SigningIssuer           string
+	SignatureFormat         string
+	SignatureFormatIssuers  []string
+	SignaturePasetoLocalKey string
+
+	// token issuance
+	TokenIssuanceEnabled bool
+	TokenDefaultTtlSec   int32
+	TokenMaxTtlSec       int32
+	TokenAudiences       []string
+	TokenSigningKeyId    string
+
+	// token introspection
+	IntrospectionClients []string
+
+	// token revocation
+	RevocationStoreFile string
+	RevocationPruneSec  int32
+
+	// onboarding records
+	OnboardingStoreFile string
+	AnswersHashKey      string
+
+	// replay protection
+	ReplayProtectionEnabled bool
+	ReplayStoreFile         string
+	ReplayPruneSec          int32
+
+	// html pages
+	WebOverrideDir string
+
+	// questionnaires
+	QuestionnairesFile   string
+	QuestionnaireClaim   string
+	QuestionnaireDefault string
 }
 
 var appConfig Configuration
@@ -59,4 +133,78 @@ func loadEnvironmentVariables() {
 	// CORS allow origins
 	appConfig.CorsAllowOrigins = utils.EnvOrDefault("CORS_ALLOW_ORIGINS", "Disabled")
 
+	// jwt verification keys
+	appConfig.JwtHmacSecret = utils.EnvOrDefault("JWT_HMAC_SECRET", "")
+	appConfig.JwtPublicKeyFile = utils.EnvOrDefault("JWT_PUBLIC_KEY_FILE", "")
+	appConfig.JwtJwksUrl = utils.EnvOrDefault("JWT_JWKS_URL", "")
+	appConfig.JwtJwksRefreshSec = utils.EnvOrDefaultInt32("JWT_JWKS_REFRESH_SEC", 300)
+	appConfig.JwtJwksMinRefetchSec = utils.EnvOrDefaultInt32("JWT_JWKS_MIN_REFETCH_SEC", 30)
+	appConfig.JwtJwksStaleIfErrorSec = utils.EnvOrDefaultInt32("JWT_JWKS_STALE_IF_ERROR_SEC", 3600)
+
+	// jwt algorithm policy
+	appConfig.JwtAlgorithms = utils.EnvOrDefaultStringSlice("JWT_ALGORITHMS", ",", nil)
+	appConfig.JwtIssuerAlgorithms = utils.EnvOrDefaultStringSlice("JWT_ISSUER_ALGORITHMS", ",", nil)
+
+	// paseto
+	appConfig.PasetoLocalKey = utils.EnvOrDefault("PASETO_LOCAL_KEY", "")
+
+	// jwt registered claims policy
+	appConfig.JwtRequireExp = utils.EnvOrDefaultBool("JWT_REQUIRE_EXP", true)
+	appConfig.JwtRequireNbf = utils.EnvOrDefaultBool("JWT_REQUIRE_NBF", false)
+	appConfig.JwtRequireIat = utils.EnvOrDefaultBool("JWT_REQUIRE_IAT", false)
+	appConfig.JwtRequireSub = utils.EnvOrDefaultBool("JWT_REQUIRE_SUB", true)
+	appConfig.JwtRequireJti = utils.EnvOrDefaultBool("JWT_REQUIRE_JTI", false)
+	appConfig.JwtIssuers = utils.EnvOrDefaultStringSlice("JWT_ISSUERS", ",", nil)
+	appConfig.JwtAudiences = utils.EnvOrDefaultStringSlice("JWT_AUDIENCES", ",", nil)
+	appConfig.JwtLeewaySec = utils.EnvOrDefaultInt32("JWT_LEEWAY_SEC", 60)
+
+	// key store
+	appConfig.KeyStoreDir = utils.EnvOrDefault("KEYSTORE_DIR", "")
+	appConfig.KeyStoreReloadSec = utils.EnvOrDefaultInt32("KEYSTORE_RELOAD_SEC", 60)
+	appConfig.JwksMaxAgeSec = utils.EnvOrDefaultInt32("JWKS_MAX_AGE_SEC", 300)
+
+	// signing key rotation
+	appConfig.KeyRotationPeriodHours = utils.EnvOrDefaultInt32("KEY_ROTATION_PERIOD_HOURS", 0)
+	appConfig.KeyRotationGraceHours = utils.EnvOrDefaultInt32("KEY_ROTATION_GRACE_HOURS", 168)
+	appConfig.KeyRotationStateFile = utils.EnvOrDefault("KEY_ROTATION_STATE_FILE", "")
+
+	// answers signing
+	appConfig.SigningAlgorithm = utils.EnvOrDefault("SIGNING_ALGORITHM", "")
+	appConfig.SigningKeyId = utils.EnvOrDefault("SIGNING_KEY_ID", "")
+	appConfig.SigningIssuer = utils.EnvOrDefault("SIGNING_ISSUER", appConfig.IngressHost)
+	appConfig.SignatureFormat = utils.EnvOrDefault("SIGNATURE_FORMAT", "jwt")
+	appConfig.SignatureFormatIssuers = utils.EnvOrDefaultStringSlice("SIGNATURE_FORMAT_ISSUERS", ",", nil)
+	appConfig.SignaturePasetoLocalKey = utils.EnvOrDefault("SIGNATURE_PASETO_LOCAL_KEY", "")
+
+	// token issuance
+	appConfig.TokenIssuanceEnabled = utils.EnvOrDefaultBool("TOKEN_ISSUANCE_ENABLED", false)
+	appConfig.TokenDefaultTtlSec = utils.EnvOrDefaultInt32("TOKEN_DEFAULT_TTL_SEC", 900)
+	appConfig.TokenMaxTtlSec = utils.EnvOrDefaultInt32("TOKEN_MAX_TTL_SEC", 3600)
+	appConfig.TokenAudiences = utils.EnvOrDefaultStringSlice("TOKEN_AUDIENCES", ",", nil)
+	appConfig.TokenSigningKeyId = utils.EnvOrDefault("TOKEN_SIGNING_KEY_ID", "")
+
+	// token introspection
+	appConfig.IntrospectionClients = utils.EnvOrDefaultStringSlice("INTROSPECTION_CLIENTS", ",", nil)
+
+	// token revocation
+	appConfig.RevocationStoreFile = utils.EnvOrDefault("REVOCATION_STORE_FILE", "")
+	appConfig.RevocationPruneSec = utils.EnvOrDefaultInt32("REVOCATION_PRUNE_SEC", 300)
+
+	// onboarding records
+	appConfig.OnboardingStoreFile = utils.EnvOrDefault("ONBOARDING_STORE_FILE", "")
+	appConfig.AnswersHashKey = utils.EnvOrDefault("ANSWERS_HASH_KEY", "")
+
+	// replay protection
+	appConfig.ReplayProtectionEnabled = utils.EnvOrDefaultBool("REPLAY_PROTECTION_ENABLED", true)
+	appConfig.ReplayStoreFile = utils.EnvOrDefault("REPLAY_STORE_FILE", "")
+	appConfig.ReplayPruneSec = utils.EnvOrDefaultInt32("REPLAY_PRUNE_SEC", 300)
+
+	// html pages
+	appConfig.WebOverrideDir = utils.EnvOrDefault("WEB_OVERRIDE_DIR", "")
+
+	// questionnaires
+	appConfig.QuestionnairesFile = utils.EnvOrDefault("QUESTIONNAIRES_FILE", "")
+	appConfig.QuestionnaireClaim = utils.EnvOrDefault("QUESTIONNAIRE_CLAIM", "questionnaire")
+	appConfig.QuestionnaireDefault = utils.EnvOrDefault("QUESTIONNAIRE_DEFAULT", "")
+
 }
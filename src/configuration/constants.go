@@ -21,4 +21,8 @@ const (
 	HtmlJwtValidationSuccessPage = "jwtvalidated.html"
 	// HtmlJwtExpired jwt expired page
 	HtmlJwtExpired = "jwtexpired.html"
+	// HtmlJwtAlreadyUsed jwt already submitted page
+	HtmlJwtAlreadyUsed = "jwtalreadyused.html"
+	// HtmlRegistrationFailed onboarding failure page
+	HtmlRegistrationFailed = "registrationfailed.html"
 )
@@ -19,11 +19,206 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys relying parties use to verify the signatures produced by validate-jwt",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "JSON Web Key Set",
+                "operationId": "jwks",
+                "responses": {
+                    "200": {
+                        "description": "The JSON Web Key Set",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "304": {
+                        "description": "The key set did not change since the ETag sent in If-None-Match"
+                    }
+                }
+            }
+        },
+        "/v1/introspect": {
+            "post": {
+                "description": "RFC 7662 token introspection. The token goes through the same validation as validate-jwt, inactive tokens only return active=false",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Introspect token",
+                "operationId": "introspect",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The token to introspect",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hint about the type of the token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The introspection result",
+                        "schema": {
+                            "$ref": "#/definitions/model.IntrospectionResult"
+                        }
+                    },
+                    "400": {
+                        "description": "The payload is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/model.JSONFailureResult"
+                        }
+                    },
+                    "401": {
+                        "description": "The client credentials are missing or invalid"
+                    }
+                }
+            }
+        },
+        "/v1/revoke": {
+            "post": {
+                "description": "RFC 7009 token revocation. The jti of a valid token is denylisted until the token expires; invalid, expired or already revoked tokens are ignored",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Revoke token",
+                "operationId": "revoke",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The token to revoke",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hint about the type of the token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The token is no longer valid"
+                    },
+                    "400": {
+                        "description": "The payload is invalid or the token has no jti",
+                        "schema": {
+                            "$ref": "#/definitions/model.JSONFailureResult"
+                        }
+                    },
+                    "401": {
+                        "description": "The client credentials are missing or invalid"
+                    },
+                    "500": {
+                        "description": "The revocation could not be stored",
+                        "schema": {
+                            "$ref": "#/definitions/model.JSONFailureResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tokens": {
+            "post": {
+                "description": "Mint a signed JWT, or a v4 PASETO, for a subject, only available when token issuance is enabled",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Issue token",
+                "operationId": "issueToken",
+                "parameters": [
+                    {
+                        "description": "token request",
+                        "name": "model.TokenIssuance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TokenIssuance"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The token was issued",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JSONSuccessResult"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.IssuedToken"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "The payload is invalid or violates the issuance policy",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JSONFailureResult"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ValidationFailure"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "The token could not be signed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JSONFailureResult"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ValidationFailure"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
         "/v1/validate-jwt": {
             "post": {
                 "description": "Validate Jwt",
                 "produces": [
-                    "text/html"
+                    "text/html",
+                    "application/json"
                 ],
                 "summary": "Validate jwt",
                 "operationId": "validateJwt",
@@ -36,13 +231,124 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.JwtValidation"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of the html page, takes precedence over the Accept-Language header",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The request was validated and has been processed successfully (sync). The html page is rendered unless the Accept header prefers application/json",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JSONSuccessResult"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.AnswersSignature"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "The payload is invalid or the answers do not match the questionnaire",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JSONFailureResult"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ValidationFailure"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "The jwt is malformed, unsigned, expired, revoked, its signature is invalid or its claims are not accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.JSONFailureResult"
+                        }
+                    },
+                    "409": {
+                        "description": "The jwt was already used",
+                        "schema": {
+                            "$ref": "#/definitions/model.JSONFailureResult"
+                        }
+                    },
+                    "500": {
+                        "description": "The answers could not be signed, or the token nonce or the onboarding record could not be stored",
+                        "schema": {
+                            "$ref": "#/definitions/model.JSONFailureResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/verify-presentation": {
+            "post": {
+                "description": "Verify an SD-JWT presentation of signed answers, carrying any subset of their disclosures, and check it was issued to the given user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/html",
+                    "application/json"
+                ],
+                "summary": "Verify presentation",
+                "operationId": "verifyPresentation",
+                "parameters": [
+                    {
+                        "description": "verify presentation",
+                        "name": "model.PresentationValidation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PresentationValidation"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of the html page, takes precedence over the Accept-Language header",
+                        "name": "lang",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "The request was validated and has been processed successfully (sync)",
+                        "description": "The presentation was verified, the disclosed answers are listed. The html page is rendered unless the Accept header prefers application/json",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JSONSuccessResult"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.PresentationVerification"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "The payload is invalid, the signature does not verify or a disclosure is not listed in it",
                         "schema": {
-                            "$ref": "#/definitions/model.JSONSuccessResult"
+                            "$ref": "#/definitions/model.JSONFailureResult"
                         }
                     }
                 }
@@ -50,12 +356,13 @@ const docTemplate = `{
         },
         "/v1/verify-signature": {
             "post": {
-                "description": "Verify signature for a given user",
+                "description": "Verify the signature cryptographically and check it was issued to the given user. When a proof is posted, the answer it holds is checked against the Merkle root of the signature",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/html"
+                    "text/html",
+                    "application/json"
                 ],
                 "summary": "Verify signature",
                 "operationId": "verifySignature",
@@ -68,17 +375,119 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.SignatureValidation"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of the html page, takes precedence over the Accept-Language header",
+                        "name": "lang",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "The request was validated and has been processed successfully (sync)",
+                        "description": "The request was validated and has been processed successfully (sync). The html page is rendered unless the Accept header prefers application/json",
                         "schema": {
-                            "$ref": "#/definitions/model.JSONSuccessResult"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JSONSuccessResult"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.SignatureVerification"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "The payload is invalid",
+                        "description": "The payload is invalid, the signature does not verify or the proof does not lead to its Merkle root",
+                        "schema": {
+                            "$ref": "#/definitions/model.JSONFailureResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/validate-jwt": {
+            "post": {
+                "description": "Validate Jwt and sign typed answers keyed by question id",
+                "produces": [
+                    "text/html",
+                    "application/json"
+                ],
+                "summary": "Validate jwt with structured answers",
+                "operationId": "validateJwtV2",
+                "parameters": [
+                    {
+                        "description": "validate signature",
+                        "name": "model.JwtValidationV2",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.JwtValidationV2"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of the html page, takes precedence over the Accept-Language header",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The request was validated and has been processed successfully (sync). The html page is rendered unless the Accept header prefers application/json",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JSONSuccessResult"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.AnswersSignature"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "The payload is invalid or the answers do not match the questionnaire",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/model.JSONFailureResult"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.ValidationFailure"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "The jwt is malformed, unsigned, expired, revoked, its signature is invalid or its claims are not accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.JSONFailureResult"
+                        }
+                    },
+                    "409": {
+                        "description": "The jwt was already used",
+                        "schema": {
+                            "$ref": "#/definitions/model.JSONFailureResult"
+                        }
+                    },
+                    "500": {
+                        "description": "The answers could not be signed, or the token nonce or the onboarding record could not be stored",
                         "schema": {
                             "$ref": "#/definitions/model.JSONFailureResult"
                         }
@@ -88,6 +497,272 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "model.AnswerItem": {
+            "type": "object",
+            "properties": {
+                "answer": {
+                    "type": "string",
+                    "example": "RO"
+                },
+                "questionId": {
+                    "type": "string",
+                    "example": "country"
+                }
+            }
+        },
+        "model.AnswerProof": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "leaf": {
+                    "type": "string",
+                    "example": "WyJzYWx0Iix7InEiOiJxdWVzdGlvbjEiLCJhIjoiYW5zd2VyMSJ9XQ"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.AnswersClaims": {
+            "type": "object",
+            "properties": {
+                "_sd_alg": {
+                    "description": "SdAlg and Answers replace the answers digest in SD-JWT signatures, Answers lists the digests of the disclosures",
+                    "type": "string",
+                    "example": "sha-256"
+                },
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DisclosureDigest"
+                    }
+                },
+                "answers_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "answers_digest": {
+                    "type": "string",
+                    "example": "ZnKHtorc20MbpdAlIRspvKpkAVnDOTIynN3lQ1M8Myg"
+                },
+                "answers_digest_alg": {
+                    "type": "string",
+                    "example": "sha-256"
+                },
+                "answers_format": {
+                    "type": "string",
+                    "example": "structured"
+                },
+                "answers_root": {
+                    "description": "AnswersRoot replaces the answers digest in Merkle signatures, the root of the tree over AnswersCount answers",
+                    "type": "string",
+                    "example": "2zx8gX08BhpsQo7lvIU_Aaq45cAbhme8vnjP2ZoKW7I"
+                },
+                "answers_root_alg": {
+                    "type": "string",
+                    "example": "sha-256"
+                },
+                "iat": {
+                    "type": "integer",
+                    "example": 1700000000
+                },
+                "iss": {
+                    "type": "string",
+                    "example": "jwt-sign"
+                },
+                "jti": {
+                    "type": "string",
+                    "example": "5f0c6e4e-8a0e-4f43-9d0e-3c1f1b0e8f1a"
+                },
+                "sub": {
+                    "type": "string",
+                    "example": "JonnyBoy"
+                }
+            }
+        },
+        "model.AnswersSignature": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "ES256"
+                },
+                "claims": {
+                    "$ref": "#/definitions/model.AnswersClaims"
+                },
+                "disclosures": {
+                    "description": "Disclosures of the answers of an SD-JWT signature, which already ends with all of them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "encrypted": {
+                    "description": "Encrypted is set when the signature is a compact JWE nesting the JWS, encrypted to the requested recipient",
+                    "type": "boolean",
+                    "example": false
+                },
+                "kid": {
+                    "type": "string",
+                    "example": "k1"
+                },
+                "proofs": {
+                    "description": "Proofs of inclusion of every answer in the Merkle root of the signature",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AnswerProof"
+                    }
+                },
+                "signature": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJFUzI1NiIsImtpZCI6ImsxIiwidHlwIjoiSldUIn0.eyJzdWIiOiJKb25ueUJveSJ9.signature"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "successfully"
+                }
+            }
+        },
+        "model.DisclosedAnswer": {
+            "type": "object",
+            "properties": {
+                "answer": {
+                    "type": "string",
+                    "example": "RO"
+                },
+                "question": {
+                    "type": "string",
+                    "example": "question1"
+                },
+                "questionId": {
+                    "type": "string",
+                    "example": "country"
+                }
+            }
+        },
+        "model.DisclosureDigest": {
+            "type": "object",
+            "properties": {
+                "...": {
+                    "type": "string",
+                    "example": "X9yH0Ajrdm1Oij4tWso9UzzKJvPoDxwmuEcO3XAdRC0"
+                }
+            }
+        },
+        "model.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "not_allowed"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "answers[1]"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "answer must be one of DE, FR, RO"
+                },
+                "questionId": {
+                    "type": "string",
+                    "example": "country"
+                }
+            }
+        },
+        "model.IntrospectionResult": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "aud": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "jwt-sign"
+                    ]
+                },
+                "client_id": {
+                    "type": "string",
+                    "example": "gateway"
+                },
+                "exp": {
+                    "type": "integer",
+                    "example": 1700000900
+                },
+                "iat": {
+                    "type": "integer",
+                    "example": 1700000000
+                },
+                "iss": {
+                    "type": "string",
+                    "example": "https://idp.example.com"
+                },
+                "jti": {
+                    "type": "string",
+                    "example": "0b8f7c8e-4e4f-4a3c-9c1e-1f0f6f3c2a11"
+                },
+                "nbf": {
+                    "type": "integer",
+                    "example": 1700000000
+                },
+                "scope": {
+                    "type": "string",
+                    "example": "read write"
+                },
+                "sub": {
+                    "type": "string",
+                    "example": "JonnyBoy"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "JonnyBoy"
+                }
+            }
+        },
+        "model.IssuedToken": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "ES256"
+                },
+                "expires_at": {
+                    "type": "integer",
+                    "example": 1700000000
+                },
+                "expires_in": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "kid": {
+                    "type": "string",
+                    "example": "k1"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJFUzI1NiIsImtpZCI6ImsxIiwidHlwIjoiSldUIn0.eyJzdWIiOiJKb25ueUJveSJ9.signature"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
         "model.JSONFailureResult": {
             "type": "object",
             "properties": {
@@ -140,6 +815,22 @@ const docTemplate = `{
                         "answer2"
                     ]
                 },
+                "encryptTo": {
+                    "description": "EncryptTo is the public JWK of the recipient the signature is encrypted to, the signature is returned in clear when empty",
+                    "type": "object"
+                },
+                "format": {
+                    "description": "Format of the signature, the format configured for the issuer of the jwt when empty",
+                    "type": "string",
+                    "enum": [
+                        "jwt",
+                        "v4.public",
+                        "v4.local",
+                        "sd-jwt",
+                        "merkle"
+                    ],
+                    "example": "jwt"
+                },
                 "jwt": {
                     "type": "string",
                     "example": "your_jwt_here"
@@ -156,18 +847,174 @@ const docTemplate = `{
                 }
             }
         },
+        "model.JwtValidationV2": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AnswerItem"
+                    }
+                },
+                "encryptTo": {
+                    "description": "EncryptTo is the public JWK of the recipient the signature is encrypted to, the signature is returned in clear when empty",
+                    "type": "object"
+                },
+                "format": {
+                    "description": "Format of the signature, the format configured for the issuer of the jwt when empty",
+                    "type": "string",
+                    "enum": [
+                        "jwt",
+                        "v4.public",
+                        "v4.local",
+                        "sd-jwt",
+                        "merkle"
+                    ],
+                    "example": "jwt"
+                },
+                "jwt": {
+                    "type": "string",
+                    "example": "your_jwt_here"
+                }
+            }
+        },
+        "model.PresentationValidation": {
+            "type": "object",
+            "properties": {
+                "presentation": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJFUzI1NiIsImtpZCI6ImsxIiwidHlwIjoiSldUIn0.eyJzdWIiOiJKb25ueUJveSJ9.signature~WyJzYWx0Iix7InEiOiJxdWVzdGlvbjEiLCJhIjoiYW5zd2VyMSJ9XQ~"
+                },
+                "user": {
+                    "type": "string",
+                    "example": "JonnyBoy"
+                }
+            }
+        },
+        "model.PresentationVerification": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "ES256"
+                },
+                "claims": {
+                    "$ref": "#/definitions/model.AnswersClaims"
+                },
+                "disclosed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DisclosedAnswer"
+                    }
+                },
+                "kid": {
+                    "type": "string",
+                    "example": "k1"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "successfully"
+                },
+                "user": {
+                    "type": "string",
+                    "example": "JonnyBoy"
+                }
+            }
+        },
         "model.SignatureValidation": {
             "type": "object",
             "properties": {
+                "proof": {
+                    "description": "Proof of one answer, checked against the Merkle root of the signature when present",
+                    "$ref": "#/definitions/model.AnswerProof"
+                },
                 "signature": {
                     "type": "string",
-                    "example": "test-signature-JonnyBoy"
+                    "example": "eyJhbGciOiJFUzI1NiIsInR5cCI6IkpXVCJ9.eyJzdWIiOiJKb25ueUJveSJ9.signature"
+                },
+                "user": {
+                    "type": "string",
+                    "example": "JonnyBoy"
+                }
+            }
+        },
+        "model.SignatureVerification": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "ES256"
+                },
+                "claims": {
+                    "$ref": "#/definitions/model.AnswersClaims"
+                },
+                "disclosed": {
+                    "description": "Disclosed is the answer proven by the proof of the request",
+                    "$ref": "#/definitions/model.DisclosedAnswer"
+                },
+                "kid": {
+                    "type": "string",
+                    "example": "k1"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "successfully"
                 },
                 "user": {
                     "type": "string",
                     "example": "JonnyBoy"
                 }
             }
+        },
+        "model.TokenIssuance": {
+            "type": "object",
+            "properties": {
+                "audience": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "jwt-sign"
+                    ]
+                },
+                "claims": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "jwt",
+                        "v4.public",
+                        "v4.local"
+                    ],
+                    "example": "jwt"
+                },
+                "subject": {
+                    "type": "string",
+                    "example": "JonnyBoy"
+                },
+                "ttl": {
+                    "type": "integer",
+                    "example": 900
+                }
+            }
+        },
+        "model.ValidationFailure": {
+            "type": "object",
+            "properties": {
+                "errorCode": {
+                    "type": "string",
+                    "example": "answers_invalid"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FieldError"
+                    }
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,182 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+const (
+	// Fallback locale of the messages missing from a catalog, its catalog is required
+	Fallback = "en"
+	// ContextKey gin context key holding the locale resolved for the request
+	ContextKey = "locale"
+	// QueryParameter query parameter selecting the locale, it takes precedence over the Accept-Language header
+	QueryParameter = "lang"
+)
+
+// Catalog maps the message keys to the messages of one locale.
+type Catalog map[string]string
+
+// Bundle holds the catalogs of all the supported locales.
+type Bundle struct {
+	catalogs map[string]Catalog
+	locales  []string
+	matcher  language.Matcher
+}
+
+// Load reads the catalogs of the directory, one <locale>.json file holding a flat JSON object per locale. Catalogs of
+// a later directory replace the messages of the same locale read from an earlier one.
+//
+// Parameters:
+//   - dirs ...fs.FS: Directories holding the catalogs
+//
+// Returns:
+//   - *Bundle: The catalogs
+//   - error: An error if a catalog cannot be parsed or the fallback catalog is missing
+func Load(dirs ...fs.FS) (*Bundle, error) {
+	catalogs := map[string]Catalog{}
+	for _, dir := range dirs {
+		files, err := fs.Glob(dir, "*.json")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := fs.ReadFile(dir, file)
+			if err != nil {
+				return nil, err
+			}
+			var messages Catalog
+			if err = json.Unmarshal(data, &messages); err != nil {
+				return nil, fmt.Errorf("error parsing catalog %s: %s", file, err.Error())
+			}
+			locale := strings.TrimSuffix(path.Base(file), ".json")
+			if _, err = language.Parse(locale); err != nil {
+				return nil, fmt.Errorf("catalog %s is not named after a locale: %s", file, err.Error())
+			}
+			if catalogs[locale] == nil {
+				catalogs[locale] = Catalog{}
+			}
+			for key, message := range messages {
+				catalogs[locale][key] = message
+			}
+		}
+	}
+	if _, ok := catalogs[Fallback]; !ok {
+		return nil, fmt.Errorf("missing the %s catalog", Fallback)
+	}
+	return NewBundle(catalogs), nil
+}
+
+// NewBundle creates a Bundle from catalogs, which must hold the fallback catalog.
+//
+// Parameters:
+//   - catalogs map[string]Catalog: The catalogs by locale
+//
+// Returns:
+//   - *Bundle: The bundle
+func NewBundle(catalogs map[string]Catalog) *Bundle {
+	// the fallback comes first, the matcher returns it when nothing matches
+	locales := []string{Fallback}
+	for locale := range catalogs {
+		if locale != Fallback {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales[1:])
+	tags := make([]language.Tag, len(locales))
+	for i, locale := range locales {
+		tags[i] = language.Make(locale)
+	}
+	return &Bundle{catalogs: catalogs, locales: locales, matcher: language.NewMatcher(tags)}
+}
+
+// Locales returns the supported locales, the fallback first.
+func (b *Bundle) Locales() []string {
+	return b.locales
+}
+
+// Resolve returns the supported locale that best matches the requested one, or the Accept-Language header when none
+// is requested.
+//
+// Parameters:
+//   - requested string: Locale requested explicitly, e.g. with a query parameter, may be empty
+//   - acceptLanguage string: Value of the Accept-Language header, may be empty
+//
+// Returns:
+//   - string: A supported locale, the fallback when nothing matches
+func (b *Bundle) Resolve(requested, acceptLanguage string) string {
+	if requested != "" {
+		if tag, err := language.Parse(requested); err == nil {
+			if _, index, confidence := b.matcher.Match(tag); confidence != language.No {
+				return b.locales[index]
+			}
+		}
+	}
+	if tags, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil && len(tags) > 0 {
+		if _, index, confidence := b.matcher.Match(tags...); confidence != language.No {
+			return b.locales[index]
+		}
+	}
+	return Fallback
+}
+
+// T returns the message of the locale formatted with args, the fallback message when the locale misses it and the key
+// itself when the fallback misses it too.
+//
+// Parameters:
+//   - locale string: Locale of the message
+//   - key string: Key of the message
+//   - args ...interface{}: Arguments of the fmt verbs of the message
+//
+// Returns:
+//   - string: The message
+func (b *Bundle) T(locale, key string, args ...interface{}) string {
+	message, ok := b.catalogs[locale][key]
+	if !ok {
+		if message, ok = b.catalogs[Fallback][key]; !ok {
+			message = key
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Missing returns, by locale, the keys of the fallback catalog a catalog has no translation for.
+func (b *Bundle) Missing() map[string][]string {
+	missing := map[string][]string{}
+	for _, locale := range b.locales[1:] {
+		for key := range b.catalogs[Fallback] {
+			if _, ok := b.catalogs[locale][key]; !ok {
+				missing[locale] = append(missing[locale], key)
+			}
+		}
+		sort.Strings(missing[locale])
+	}
+	return missing
+}
+
+// Middleware resolves the locale of each request from the lang query parameter or the Accept-Language header and
+// stores it in the gin context under ContextKey.
+//
+// Parameters:
+//   - b *Bundle: The supported catalogs
+//
+// Returns:
+//   - gin.HandlerFunc: The middleware
+func Middleware(b *Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := b.Resolve(c.Query(QueryParameter), c.GetHeader("Accept-Language"))
+		c.Set(ContextKey, locale)
+		c.Header("Content-Language", locale)
+		c.Next()
+	}
+}
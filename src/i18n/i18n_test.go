@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBundle() *Bundle {
+	return NewBundle(map[string]Catalog{
+		"en": {"greeting": "Hello %s", "farewell": "Goodbye"},
+		"de": {"greeting": "Hallo %s"},
+		"fr": {"greeting": "Bonjour %s", "farewell": "Au revoir"},
+	})
+}
+
+func TestResolve(t *testing.T) {
+	b := testBundle()
+	assert.Equal(t, []string{"en", "de", "fr"}, b.Locales())
+
+	assert.Equal(t, "de", b.Resolve("", "de-AT,de;q=0.9,en;q=0.5"))
+	assert.Equal(t, "fr", b.Resolve("", "it;q=1.0,fr;q=0.8"))
+	// the requested locale takes precedence over the header
+	assert.Equal(t, "fr", b.Resolve("fr-CA", "de"))
+	// an unsupported or invalid request falls back on the header, then on the fallback
+	assert.Equal(t, "de", b.Resolve("ja", "de"))
+	assert.Equal(t, "de", b.Resolve("not a locale", "de"))
+	assert.Equal(t, Fallback, b.Resolve("", "ja"))
+	assert.Equal(t, Fallback, b.Resolve("", ""))
+}
+
+func TestT(t *testing.T) {
+	b := testBundle()
+	assert.Equal(t, "Hallo JonnyBoy", b.T("de", "greeting", "JonnyBoy"))
+	assert.Equal(t, "Goodbye", b.T("de", "farewell"))
+	assert.Equal(t, "Goodbye", b.T("ja", "farewell"))
+	assert.Equal(t, "unknown.key", b.T("de", "unknown.key"))
+}
+
+func TestMissing(t *testing.T) {
+	assert.Equal(t, map[string][]string{"de": {"farewell"}}, testBundle().Missing())
+}
+
+func TestLoad(t *testing.T) {
+	embedded := fstest.MapFS{
+		"en.json": {Data: []byte(`{"greeting": "Hello %s", "farewell": "Goodbye"}`)},
+		"de.json": {Data: []byte(`{"greeting": "Hallo %s"}`)},
+	}
+	override := fstest.MapFS{
+		"de.json": {Data: []byte(`{"farewell": "Tschüss"}`)},
+	}
+	b, err := Load(embedded, override)
+	require.NoError(t, err)
+	assert.Equal(t, "Hallo JonnyBoy", b.T("de", "greeting", "JonnyBoy"))
+	assert.Equal(t, "Tschüss", b.T("de", "farewell"))
+	assert.Empty(t, b.Missing())
+
+	for name, dir := range map[string]fstest.MapFS{
+		"no fallback":  {"de.json": {Data: []byte(`{}`)}},
+		"invalid json": {"en.json": {Data: []byte(`{"greeting": 1}`)}},
+		"not a locale": {"en.json": {Data: []byte(`{}`)}, "messages.json": {Data: []byte(`{}`)}},
+	} {
+		_, err = Load(dir)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(testBundle()))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(ContextKey))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/?"+QueryParameter+"=fr", nil)
+	req.Header.Set("Accept-Language", "de")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "fr", w.Body.String())
+	assert.Equal(t, "fr", w.Header().Get("Content-Language"))
+}
@@ -0,0 +1,282 @@
+package keystore
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// FileKeyStore is a KeyStore loading PEM and JWK/JWKS files from a directory.
+//
+// PEM files (.pem, .key, .crt) may hold PKCS#1, PKCS#8 or SEC1 private keys, PKIX or PKCS#1 public keys and
+// certificates; the kid is the file name up to the first dot, so k1.pem and k1.pub.pem describe the same key. PEM keys
+// sign with the algorithm usually associated with their type, except the signing key which signs with the configured
+// algorithm when there is one. JWK files (.json, .jwk, .jwks) may hold a single key or a key set and carry their own kid.
+type FileKeyStore struct {
+	*MemoryKeyStore
+	dir string
+	// algorithm of the signing key when it is a PEM key, derived from its type when empty
+	algorithm string
+}
+
+// NewFileKeyStore creates a key store and loads the keys found in dir.
+//
+// Parameters:
+//   - dir string: Directory holding the key files
+//   - signingKeyId string: kid of the key used for signing, the only private key is used when empty
+//   - algorithm string: JWS algorithm of the signing key when it is a PEM key, derived from its type when empty
+//
+// Returns:
+//   - *FileKeyStore: The key store
+//   - error: An error if the directory or one of the key files cannot be read or parsed, or the signing key cannot sign
+//     with the algorithm
+func NewFileKeyStore(dir, signingKeyId, algorithm string) (*FileKeyStore, error) {
+	s := &FileKeyStore{MemoryKeyStore: NewMemoryKeyStore(signingKeyId), dir: dir, algorithm: algorithm}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the key files again, replacing the keys held by the store.
+func (s *FileKeyStore) Reload() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("error reading key store directory %s: %s", s.dir, err.Error())
+	}
+	keys := map[string]*Key{}
+	// kids of the keys read from PEM files, which carry no algorithm of their own
+	pemKeys := map[string]bool{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		var loaded []*Key
+		isPem := false
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".pem", ".key", ".crt":
+			loaded, err = loadPemFile(path)
+			isPem = true
+		case ".json", ".jwk", ".jwks":
+			loaded, err = loadJwkFile(path)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, k := range loaded {
+			// a private key wins over the public half of the same key found in another file
+			if existing, ok := keys[k.Id]; ok && existing.CanSign() && !k.CanSign() {
+				continue
+			}
+			keys[k.Id] = k
+			pemKeys[k.Id] = isPem
+		}
+	}
+	if err = s.applyAlgorithm(keys, pemKeys); err != nil {
+		return err
+	}
+	list := make([]*Key, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, k)
+	}
+	s.set(list)
+	return nil
+}
+
+// Watch reloads the key files every interval until ctx is cancelled, so rotated keys are picked up without a
+// restart. A failed reload keeps the previously loaded keys.
+func (s *FileKeyStore) Watch(ctx context.Context, interval time.Duration) {
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().With("package", "keystore", "action", "Watch")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debugf("key store watcher terminated")
+			return
+		case <-ticker.C:
+			if err := s.Reload(); err != nil {
+				log.Errorf("error reloading key store, keeping the previous keys: %s", err.Error())
+			}
+		}
+	}
+}
+
+// applyAlgorithm sets the configured algorithm on the signing key when it was read from a PEM file: the key named by
+// the signing key id, or else the only private key.
+func (s *FileKeyStore) applyAlgorithm(keys map[string]*Key, pemKeys map[string]bool) error {
+	if s.algorithm == "" {
+		return nil
+	}
+	kid := s.signingKeyId
+	if kid == "" {
+		for id, k := range keys {
+			if !k.CanSign() {
+				continue
+			}
+			if kid != "" {
+				// the signing key is ambiguous, SigningKey reports it
+				return nil
+			}
+			kid = id
+		}
+	}
+	key, ok := keys[kid]
+	if !ok || !pemKeys[kid] {
+		return nil
+	}
+	if !signsWith(key.Public, s.algorithm) {
+		return fmt.Errorf("error loading signing key %s: the key cannot sign with %s", kid, s.algorithm)
+	}
+	key.Algorithm = s.algorithm
+	return nil
+}
+
+// loadPemFile parses every PEM block of the file. The keys sign with the algorithm usually associated with their type.
+func loadPemFile(path string) ([]*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file %s: %s", path, err.Error())
+	}
+	kid := strings.SplitN(filepath.Base(path), ".", 2)[0]
+	var keys []*Key
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		material, err := parsePemBlock(block)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing key file %s: %s", path, err.Error())
+		}
+		key, err := newKey(kid, "", "", material)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing key file %s: %s", path, err.Error())
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("error parsing key file %s: no PEM block found", path)
+	}
+	return keys, nil
+}
+
+// parsePemBlock decodes a PKCS#1, PKCS#8, SEC1, PKIX or certificate PEM block.
+func parsePemBlock(block *pem.Block) (interface{}, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+}
+
+// loadJwkFile parses a file holding a JWK or a JWKS.
+func loadJwkFile(path string) ([]*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file %s: %s", path, err.Error())
+	}
+	keys, skipped, err := ParseJwks(data)
+	logSkippedKeys(path, skipped)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing key file %s: %s", path, err.Error())
+	}
+	return keys, nil
+}
+
+// logSkippedKeys logs the keys of a key set that ParseJwks skipped.
+func logSkippedKeys(source string, skipped []error) {
+	if len(skipped) == 0 {
+		return
+	}
+	log := logger.SugaredLogger().With("package", "keystore", "action", "ParseJwks")
+	for _, err := range skipped {
+		log.Warnf("skipping unusable key of the key set from %s: %s", source, err.Error())
+	}
+}
+
+// ParseJwks parses a JSON document holding either a single JWK or a JWK set. Keys without a kid get their
+// RFC 7638 thumbprint as kid. Keys of a set that cannot be used, such as keys of an unsupported type or curve, are
+// skipped so that the other keys of the set remain usable.
+//
+// Parameters:
+//   - data []byte: The JSON document
+//
+// Returns:
+//   - []*Key: The keys
+//   - []error: Why each skipped key of the set cannot be used, for the caller to log
+//   - error: An error if the document cannot be parsed, the single JWK is invalid, or no key of a non empty set is
+//     usable
+func ParseJwks(data []byte) ([]*Key, []error, error) {
+	var probe struct {
+		Keys json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, nil, err
+	}
+	if probe.Keys == nil {
+		var jwk jose.JSONWebKey
+		if err := json.Unmarshal(data, &jwk); err != nil {
+			return nil, nil, err
+		}
+		key, err := FromJwk(jwk)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []*Key{key}, nil, nil
+	}
+
+	var set []json.RawMessage
+	if err := json.Unmarshal(probe.Keys, &set); err != nil {
+		return nil, nil, err
+	}
+	keys := make([]*Key, 0, len(set))
+	var skipped []error
+	for i, raw := range set {
+		var jwk jose.JSONWebKey
+		err := json.Unmarshal(raw, &jwk)
+		var key *Key
+		if err == nil {
+			key, err = FromJwk(jwk)
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("key %d: %s", i, err.Error()))
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 && len(skipped) > 0 {
+		return nil, skipped, fmt.Errorf("no usable key in the key set, %d skipped", len(skipped))
+	}
+	return keys, skipped, nil
+}
@@ -0,0 +1,219 @@
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// writePem writes the private key as PKCS#8, or the public key as PKIX, to the file of dir.
+func writePem(t *testing.T, dir, name string, key interface{}) {
+	t.Helper()
+	var block *pem.Block
+	if private, err := x509.MarshalPKCS8PrivateKey(key); err == nil {
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: private}
+	} else {
+		public, err := x509.MarshalPKIXPublicKey(key)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: public}
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0600))
+}
+
+func rsaKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func ecKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func algorithms(keys []*Key) map[string]string {
+	m := map[string]string{}
+	for _, k := range keys {
+		m[k.Id] = k.Algorithm
+	}
+	return m
+}
+
+func TestFileKeyStoreLoadsKeys(t *testing.T) {
+	dir := t.TempDir()
+	signing := rsaKey(t)
+	writePem(t, dir, "k1.pem", signing)
+	writePem(t, dir, "k1.pub.pem", &signing.PublicKey)
+	writePem(t, dir, "k2.pub.pem", &ecKey(t, elliptic.P384()).PublicKey)
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePem(t, dir, "k3.key", ed)
+	jwk, err := Generate("PS512", "k4")
+	require.NoError(t, err)
+	data, err := json.Marshal(PublicJwks([]*Key{jwk}))
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "k4.jwks"), data, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0600))
+
+	ks, err := NewFileKeyStore(dir, "k1", "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k1": "RS256", "k2": "ES384", "k3": "EdDSA", "k4": "PS512"}, algorithms(ks.Keys()))
+
+	// the private key wins over its public half
+	key, err := ks.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.Id)
+	assert.Equal(t, signing, key.Private)
+
+	// several private keys require a signing key id
+	ks, err = NewFileKeyStore(dir, "", "")
+	require.NoError(t, err)
+	_, err = ks.SigningKey()
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func TestFileKeyStoreSigningAlgorithm(t *testing.T) {
+	for _, alg := range []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"} {
+		dir := t.TempDir()
+		writePem(t, dir, "k1.pem", rsaKey(t))
+		ks, err := NewFileKeyStore(dir, "", alg)
+		require.NoError(t, err, alg)
+		key, err := ks.SigningKey()
+		require.NoError(t, err, alg)
+		assert.Equal(t, alg, key.Algorithm)
+	}
+	for curve, alg := range map[elliptic.Curve]string{elliptic.P256(): "ES256", elliptic.P384(): "ES384", elliptic.P521(): "ES512"} {
+		dir := t.TempDir()
+		writePem(t, dir, "k1.pem", ecKey(t, curve))
+		ks, err := NewFileKeyStore(dir, "", alg)
+		require.NoError(t, err, alg)
+		assert.Equal(t, map[string]string{"k1": alg}, algorithms(ks.Keys()))
+	}
+}
+
+func TestFileKeyStoreRejectsIncompatibleAlgorithm(t *testing.T) {
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		key interface{}
+		alg string
+	}{
+		{rsaKey(t), "ES256"},
+		{rsaKey(t), "EdDSA"},
+		{rsaKey(t), "HS256"},
+		{rsaKey(t), "RS1024"},
+		{ecKey(t, elliptic.P384()), "ES256"},
+		{ecKey(t, elliptic.P256()), "ES512"},
+		{ecKey(t, elliptic.P256()), "RS256"},
+		{ed, "ES256"},
+	} {
+		dir := t.TempDir()
+		writePem(t, dir, "k1.pem", tc.key)
+		_, err = NewFileKeyStore(dir, "", tc.alg)
+		assert.Error(t, err, "%T %s", tc.key, tc.alg)
+	}
+}
+
+func TestFileKeyStoreMixedKeyTypes(t *testing.T) {
+	dir := t.TempDir()
+	signing := rsaKey(t)
+	writePem(t, dir, "k1.pem", signing)
+	writePem(t, dir, "k1.pub.pem", &signing.PublicKey)
+	writePem(t, dir, "k2.pem", ecKey(t, elliptic.P384()))
+	writePem(t, dir, "k3.pub.pem", &rsaKey(t).PublicKey)
+
+	// the configured algorithm applies to the signing key only, the others keep the algorithm of their type
+	ks, err := NewFileKeyStore(dir, "k1", "PS256")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k1": "PS256", "k2": "ES384", "k3": "RS256"}, algorithms(ks.Keys()))
+	ks, err = NewFileKeyStore(dir, "k2", "ES384")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k1": "RS256", "k2": "ES384", "k3": "RS256"}, algorithms(ks.Keys()))
+
+	// a signing key of another type still fails to load
+	_, err = NewFileKeyStore(dir, "k2", "PS256")
+	assert.Error(t, err)
+}
+
+func TestParseJwksAlgorithm(t *testing.T) {
+	rsaPrivate := rsaKey(t)
+	ecPrivate := ecKey(t, elliptic.P256())
+	for name, jwk := range map[string]jose.JSONWebKey{
+		"rsa":          {Key: &rsaPrivate.PublicKey, KeyID: "k1", Algorithm: "PS384"},
+		"ec":           {Key: &ecPrivate.PublicKey, KeyID: "k1", Algorithm: "ES256"},
+		"no algorithm": {Key: &ecPrivate.PublicKey, KeyID: "k1"},
+		"encryption":   {Key: &rsaPrivate.PublicKey, KeyID: "k1", Algorithm: "RSA-OAEP-256", Use: UseEncryption},
+	} {
+		data, err := json.Marshal(jwk)
+		require.NoError(t, err, name)
+		_, _, err = ParseJwks(data)
+		assert.NoError(t, err, name)
+	}
+	for name, jwk := range map[string]jose.JSONWebKey{
+		"rsa with es256":      {Key: &rsaPrivate.PublicKey, KeyID: "k1", Algorithm: "ES256"},
+		"p256 with es384":     {Key: &ecPrivate.PublicKey, KeyID: "k1", Algorithm: "ES384"},
+		"ec with rs256":       {Key: &ecPrivate.PublicKey, KeyID: "k1", Algorithm: "RS256"},
+		"secret with rs256":   {Key: []byte("secret"), KeyID: "k1", Algorithm: "RS256"},
+		"encryption with sig": {Key: &rsaPrivate.PublicKey, KeyID: "k1", Algorithm: "RSA-OAEP-256", Use: UseSignature},
+	} {
+		data, err := json.Marshal(jwk)
+		require.NoError(t, err, name)
+		_, _, err = ParseJwks(data)
+		assert.Error(t, err, name)
+	}
+}
+
+// mixedJwks returns a key set holding the public half of the key among keys that cannot be used.
+func mixedJwks(t *testing.T, key *Key) []byte {
+	t.Helper()
+	usable, err := json.Marshal(PublicJwks([]*Key{key}).Keys[0])
+	require.NoError(t, err)
+	mismatch, err := json.Marshal(jose.JSONWebKey{Key: &rsaKey(t).PublicKey, KeyID: "mismatch", Algorithm: "ES256"})
+	require.NoError(t, err)
+	return []byte(`{"keys":[
+		{"kty":"OKP","crv":"X448","kid":"x448","x":"AAAA"},
+		{"kty":"EC","crv":"P-192","kid":"p192","x":"AAAA","y":"AAAA"},
+		{"kty":"RSA","kid":"broken","n":"!!","e":"AQAB"},
+		{"kty":"unknown","kid":"unknown"},
+		"not a key",
+		` + string(mismatch) + `,
+		` + string(usable) + `
+	]}`)
+}
+
+func TestParseJwksSkipsUnusableKeys(t *testing.T) {
+	key, err := Generate("ES256", "k1")
+	require.NoError(t, err)
+
+	keys, skipped, err := ParseJwks(mixedJwks(t, key))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k1"}, kids(keys))
+	assert.Len(t, skipped, 6)
+
+	// the set fails only when none of its keys is usable
+	_, skipped, err = ParseJwks([]byte(`{"keys":[{"kty":"OKP","crv":"X448","x":"AAAA"},{"kty":"unknown"}]}`))
+	assert.Error(t, err)
+	assert.Len(t, skipped, 2)
+	keys, _, err = ParseJwks([]byte(`{"keys":[]}`))
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	for _, document := range []string{`{"keys":`, `{"keys":{}}`, `{"kty":"OKP","crv":"X448","x":"AAAA"}`} {
+		_, _, err = ParseJwks([]byte(document))
+		assert.Error(t, err, document)
+	}
+}
@@ -0,0 +1,64 @@
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Generate creates a random key for the JWS algorithm. A random kid is assigned when kid is empty.
+//
+// Parameters:
+//   - alg string: JWS algorithm the key is generated for
+//   - kid string: Key id
+//
+// Returns:
+//   - *Key: The generated key
+//   - error: An error if the algorithm is not supported
+func Generate(alg, kid string) (*Key, error) {
+	var (
+		material interface{}
+		err      error
+	)
+	switch alg {
+	case "HS256", "HS384", "HS512":
+		secret := make([]byte, secretSize(alg))
+		_, err = rand.Read(secret)
+		material = secret
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		material, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		material, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		material, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		material, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "EdDSA":
+		_, material, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("cannot generate a key for algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if kid == "" {
+		kid = uuid.New().String()
+	}
+	return newKey(kid, alg, UseSignature, material)
+}
+
+// secretSize returns the HMAC secret size matching the hash of an HS* algorithm.
+func secretSize(alg string) int {
+	switch alg {
+	case "HS384":
+		return 48
+	case "HS512":
+		return 64
+	}
+	return 32
+}
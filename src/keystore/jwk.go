@@ -0,0 +1,56 @@
+package keystore
+
+import (
+	"crypto"
+	"encoding/base64"
+	"fmt"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// FromJwk converts a JWK to a Key. A missing kid is replaced by the RFC 7638 thumbprint of asymmetric keys, and the
+// algorithm of a signature key must suit its type.
+func FromJwk(jwk jose.JSONWebKey) (*Key, error) {
+	kid := jwk.KeyID
+	if secret, ok := jwk.Key.([]byte); ok {
+		if len(secret) == 0 || kid == "" {
+			return nil, fmt.Errorf("invalid symmetric JWK %q: a non empty secret and a kid are required", kid)
+		}
+	} else {
+		if !jwk.Valid() {
+			return nil, fmt.Errorf("invalid JWK %q", kid)
+		}
+		if kid == "" {
+			thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+			if err != nil {
+				return nil, err
+			}
+			kid = base64.RawURLEncoding.EncodeToString(thumbprint)
+		}
+	}
+	key, err := newKey(kid, jwk.Algorithm, jwk.Use, jwk.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key.Use != UseEncryption && !signsWith(key.Public, key.Algorithm) {
+		return nil, fmt.Errorf("invalid JWK %q: the key cannot sign with %s", kid, key.Algorithm)
+	}
+	return key, nil
+}
+
+// PublicJwks returns the JWK set of the public halves of the asymmetric keys. Symmetric keys are never published.
+func PublicJwks(keys []*Key) jose.JSONWebKeySet {
+	set := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	for _, k := range keys {
+		if k.IsSymmetric() {
+			continue
+		}
+		set.Keys = append(set.Keys, jose.JSONWebKey{
+			Key:       k.Public,
+			KeyID:     k.Id,
+			Algorithm: k.Algorithm,
+			Use:       k.Use,
+		})
+	}
+	return set
+}
@@ -0,0 +1,289 @@
+package keystore
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"jwt-sign/configuration"
+)
+
+const (
+	// UseSignature value of the JWK use parameter for signature keys
+	UseSignature = "sig"
+	// UseEncryption value of the JWK use parameter for encryption keys
+	UseEncryption = "enc"
+)
+
+// defaultSigningAlgorithm is the algorithm of generated keys when none is configured
+const defaultSigningAlgorithm = "ES256"
+
+var (
+	// ErrKeyNotFound no key is registered under the requested kid
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrNoSigningKey the key store holds no private key that can be used for signing
+	ErrNoSigningKey = errors.New("no signing key available")
+)
+
+// Key is a signing or verification key identified by its kid.
+type Key struct {
+	Id        string
+	Algorithm string
+	Use       string
+	// Private is the private key or the shared secret, nil for verification only keys
+	Private interface{}
+	// Public is the public key, or the shared secret for symmetric keys
+	Public interface{}
+	// CreatedAt is when a generated key was created, zero for keys loaded from files
+	CreatedAt time.Time
+	// RetiredAt is when the key stopped signing, zero while the key is active
+	RetiredAt time.Time
+}
+
+// CanSign returns true if the key is a signature key holding private material.
+func (k *Key) CanSign() bool {
+	return k.Private != nil && k.Use == UseSignature
+}
+
+// CanDecrypt returns true if the key is an encryption key holding private material.
+func (k *Key) CanDecrypt() bool {
+	return k.Private != nil && k.Use == UseEncryption
+}
+
+// IsSymmetric returns true for shared secret keys.
+func (k *Key) IsSymmetric() bool {
+	_, ok := k.Public.([]byte)
+	return ok
+}
+
+// KeyStore looks up signing and verification keys.
+type KeyStore interface {
+	// SigningKey returns the key currently used to sign.
+	SigningKey() (*Key, error)
+	// VerificationKey returns the key registered under kid.
+	VerificationKey(kid string) (*Key, error)
+	// Keys returns all the keys in the store, ordered by kid.
+	Keys() []*Key
+}
+
+var keyStore KeyStore
+
+// Init builds the package key store from the application configuration. With key rotation enabled the keys are
+// generated and rotated on schedule until ctx is cancelled. Otherwise keys are loaded from the configured
+// directory and reloaded periodically; when no directory is configured an ephemeral key is generated for the
+// signing algorithm, ES256 unless configured.
+func Init(ctx context.Context, conf *configuration.Configuration) error {
+	log := logger.SugaredLogger().With("package", "keystore", "action", "Init")
+	algorithm := conf.SigningAlgorithm
+	if algorithm == "" {
+		algorithm = defaultSigningAlgorithm
+	}
+	if conf.KeyRotationPeriodHours > 0 {
+		if conf.KeyRotationStateFile == "" {
+			return fmt.Errorf("key rotation requires a state file to persist the keys")
+		}
+		if conf.KeyStoreDir != "" {
+			log.Warnf("key rotation is enabled, ignoring the keys in %s", conf.KeyStoreDir)
+		}
+		ks, err := NewRotatingKeyStore(conf.KeyRotationStateFile, algorithm,
+			time.Duration(conf.KeyRotationPeriodHours)*time.Hour, time.Duration(conf.KeyRotationGraceHours)*time.Hour)
+		if err != nil {
+			return err
+		}
+		log.Infof("key rotation enabled, %d keys loaded from %s", len(ks.Keys()), conf.KeyRotationStateFile)
+		concurrency.GlobalWaitGroup.Add(1)
+		go ks.Watch(ctx)
+		keyStore = ks
+		return nil
+	}
+	if conf.KeyStoreDir != "" {
+		ks, err := NewFileKeyStore(conf.KeyStoreDir, conf.SigningKeyId, conf.SigningAlgorithm)
+		if err != nil {
+			return err
+		}
+		log.Infof("loaded %d keys from %s", len(ks.Keys()), conf.KeyStoreDir)
+		if conf.KeyStoreReloadSec > 0 {
+			concurrency.GlobalWaitGroup.Add(1)
+			go ks.Watch(ctx, time.Duration(conf.KeyStoreReloadSec)*time.Second)
+		}
+		keyStore = ks
+		return nil
+	}
+	key, err := Generate(algorithm, conf.SigningKeyId)
+	if err != nil {
+		return err
+	}
+	log.Warnf("no key store directory configured, generated an ephemeral %s key. Signatures will not verify after a restart!", key.Algorithm)
+	keyStore = NewMemoryKeyStore(key.Id, key)
+	return nil
+}
+
+// Default returns the package key store initialized by Init.
+func Default() KeyStore {
+	return keyStore
+}
+
+// MemoryKeyStore is a KeyStore holding a fixed set of keys.
+type MemoryKeyStore struct {
+	mu           sync.RWMutex
+	keys         map[string]*Key
+	signingKeyId string
+}
+
+// NewMemoryKeyStore creates a key store holding the given keys.
+//
+// Parameters:
+//   - signingKeyId string: kid of the key used for signing, the only private key is used when empty
+//   - keys ...*Key: The keys
+func NewMemoryKeyStore(signingKeyId string, keys ...*Key) *MemoryKeyStore {
+	s := &MemoryKeyStore{signingKeyId: signingKeyId}
+	s.set(keys)
+	return s
+}
+
+// set replaces the keys held by the store.
+func (s *MemoryKeyStore) set(keys []*Key) {
+	m := make(map[string]*Key, len(keys))
+	for _, k := range keys {
+		m[k.Id] = k
+	}
+	s.mu.Lock()
+	s.keys = m
+	s.mu.Unlock()
+}
+
+// SigningKey returns the configured signing key, or the only private key when none is configured.
+func (s *MemoryKeyStore) SigningKey() (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.signingKeyId != "" {
+		if k, ok := s.keys[s.signingKeyId]; ok && k.CanSign() {
+			return k, nil
+		}
+		return nil, fmt.Errorf("%w: no private key with kid %q", ErrNoSigningKey, s.signingKeyId)
+	}
+	var found *Key
+	for _, k := range s.keys {
+		if !k.CanSign() {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%w: several private keys found, configure the signing key id", ErrNoSigningKey)
+		}
+		found = k
+	}
+	if found == nil {
+		return nil, ErrNoSigningKey
+	}
+	return found, nil
+}
+
+// VerificationKey returns the key registered under kid.
+func (s *MemoryKeyStore) VerificationKey(kid string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if k, ok := s.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+}
+
+// Keys returns all the keys, ordered by kid.
+func (s *MemoryKeyStore) Keys() []*Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]*Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Id < keys[j].Id })
+	return keys
+}
+
+// newKey builds a Key from private or public key material, deriving the public half and the default algorithm.
+func newKey(kid, alg, use string, material interface{}) (*Key, error) {
+	k := &Key{Id: kid, Algorithm: alg, Use: use}
+	switch m := material.(type) {
+	case []byte:
+		k.Private, k.Public = m, m
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		k.Private = m
+		k.Public = m.(crypto.Signer).Public()
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		k.Public = m
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", material)
+	}
+	if k.Use == "" {
+		k.Use = UseSignature
+	}
+	if k.Algorithm == "" && k.Use == UseEncryption {
+		k.Algorithm = defaultEncryptionAlgorithm(k.Public)
+	} else if k.Algorithm == "" {
+		k.Algorithm = defaultAlgorithm(k.Public)
+	}
+	return k, nil
+}
+
+// defaultAlgorithm returns the JWS algorithm usually associated with a public key.
+func defaultAlgorithm(public interface{}) string {
+	switch p := public.(type) {
+	case []byte:
+		return "HS256"
+	case *rsa.PublicKey:
+		return "RS256"
+	case *ecdsa.PublicKey:
+		switch p.Curve {
+		case elliptic.P384():
+			return "ES384"
+		case elliptic.P521():
+			return "ES512"
+		}
+		return "ES256"
+	case ed25519.PublicKey:
+		return "EdDSA"
+	}
+	return ""
+}
+
+// signsWith reports whether the public key can sign with the JWS algorithm, EC keys only with the algorithm of their
+// curve.
+func signsWith(public interface{}, alg string) bool {
+	switch p := public.(type) {
+	case []byte:
+		return alg == "HS256" || alg == "HS384" || alg == "HS512"
+	case *rsa.PublicKey:
+		switch alg {
+		case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+			return true
+		}
+	case *ecdsa.PublicKey:
+		return alg == defaultAlgorithm(p)
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	}
+	return false
+}
+
+// defaultEncryptionAlgorithm returns the JWE key management algorithm usually associated with a key.
+func defaultEncryptionAlgorithm(public interface{}) string {
+	switch public.(type) {
+	case []byte:
+		return "A256KW"
+	case *rsa.PublicKey:
+		return "RSA-OAEP-256"
+	case *ecdsa.PublicKey:
+		return "ECDH-ES+A256KW"
+	}
+	return ""
+}
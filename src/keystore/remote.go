@@ -0,0 +1,197 @@
+package keystore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+)
+
+// maxJwksSize upper bound of a remote JWKS document
+const maxJwksSize = 1 << 20
+
+// RemoteJwks is a verification only KeyStore backed by the JWKS document of an identity provider.
+//
+// Keys are cached in memory and refreshed in the background. A kid missing from the cache triggers a refetch,
+// at most once per minRefetch. When the provider is unreachable the cached keys keep being served for
+// staleIfError past their refresh deadline.
+type RemoteJwks struct {
+	url          string
+	client       *http.Client
+	refresh      time.Duration
+	minRefetch   time.Duration
+	staleIfError time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]*Key
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	fetchMu     sync.Mutex
+}
+
+// NewRemoteJwks creates a key store for the JWKS published at url. No request is made until the first lookup
+// or refresh.
+//
+// Parameters:
+//   - url string: URL of the JWKS document
+//   - client *http.Client: HTTP client used to fetch the document, http.DefaultClient when nil
+//   - refresh time.Duration: Interval between background refreshes
+//   - minRefetch time.Duration: Minimum interval between two fetches triggered by unknown kids
+//   - staleIfError time.Duration: How long cached keys outlive a failing refresh
+func NewRemoteJwks(url string, client *http.Client, refresh, minRefetch, staleIfError time.Duration) *RemoteJwks {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RemoteJwks{
+		url:          url,
+		client:       client,
+		refresh:      refresh,
+		minRefetch:   minRefetch,
+		staleIfError: staleIfError,
+		keys:         map[string]*Key{},
+	}
+}
+
+// SigningKey always fails, remote keys are only used for verification.
+func (r *RemoteJwks) SigningKey() (*Key, error) {
+	return nil, ErrNoSigningKey
+}
+
+// VerificationKey returns the cached key registered under kid, refetching the document when the kid is unknown.
+func (r *RemoteJwks) VerificationKey(kid string) (*Key, error) {
+	if k, ok := r.cached(kid); ok {
+		return k, nil
+	}
+	if err := r.refetch(context.Background()); err != nil {
+		return nil, fmt.Errorf("%w: %q (%s)", ErrKeyNotFound, kid, err.Error())
+	}
+	if k, ok := r.cached(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+}
+
+// Keys returns the cached keys, ordered by kid. Nothing is returned once the cache went stale.
+func (r *RemoteJwks) Keys() []*Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.usable(time.Now()) {
+		return nil
+	}
+	keys := make([]*Key, 0, len(r.keys))
+	for _, k := range r.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Id < keys[j].Id })
+	return keys
+}
+
+// Refresh fetches the JWKS document and replaces the cached keys.
+func (r *RemoteJwks) Refresh(ctx context.Context) error {
+	r.fetchMu.Lock()
+	defer r.fetchMu.Unlock()
+	return r.fetch(ctx)
+}
+
+// Watch refreshes the cached keys every refresh interval until ctx is cancelled.
+func (r *RemoteJwks) Watch(ctx context.Context) {
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().With("package", "keystore", "action", "RemoteJwksWatch")
+	ticker := time.NewTicker(r.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debugf("remote jwks watcher terminated")
+			return
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil {
+				log.Errorf("error refreshing jwks from %s, serving cached keys: %s", r.url, err.Error())
+			}
+		}
+	}
+}
+
+// cached returns the key registered under kid if the cache is still usable.
+func (r *RemoteJwks) cached(kid string) (*Key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.usable(time.Now()) {
+		return nil, false
+	}
+	k, ok := r.keys[kid]
+	return k, ok
+}
+
+// usable reports whether the cached keys may still be served. Callers must hold mu.
+func (r *RemoteJwks) usable(now time.Time) bool {
+	return !r.fetchedAt.IsZero() && now.Before(r.fetchedAt.Add(r.refresh+r.staleIfError))
+}
+
+// refetch fetches the document unless another fetch happened less than minRefetch ago.
+func (r *RemoteJwks) refetch(ctx context.Context) error {
+	r.fetchMu.Lock()
+	defer r.fetchMu.Unlock()
+	r.mu.RLock()
+	lastAttempt := r.lastAttempt
+	r.mu.RUnlock()
+	if time.Since(lastAttempt) < r.minRefetch {
+		return fmt.Errorf("jwks was fetched less than %s ago", r.minRefetch)
+	}
+	return r.fetch(ctx)
+}
+
+// fetch downloads and parses the document. Callers must hold fetchMu.
+func (r *RemoteJwks) fetch(ctx context.Context) error {
+	r.mu.Lock()
+	r.lastAttempt = time.Now()
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d fetching %s", resp.StatusCode, r.url)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxJwksSize))
+	if err != nil {
+		return err
+	}
+	keys, skipped, err := ParseJwks(body)
+	logSkippedKeys(r.url, skipped)
+	if err != nil {
+		return fmt.Errorf("error parsing jwks from %s: %s", r.url, err.Error())
+	}
+	m := make(map[string]*Key, len(keys))
+	for _, k := range keys {
+		if k.Use != UseSignature || k.IsSymmetric() {
+			continue
+		}
+		m[k.Id] = k
+	}
+	if len(m) == 0 {
+		return fmt.Errorf("no asymmetric signature key in the jwks from %s", r.url)
+	}
+	r.mu.Lock()
+	r.keys = m
+	r.fetchedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,255 @@
+package keystore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testIdP publishes the public halves of its keys as a JWKS document, or fails with its status when set.
+type testIdP struct {
+	server  *httptest.Server
+	mu      sync.Mutex
+	keys    []*Key
+	status  int
+	fetches int32
+}
+
+func newTestIdP(t *testing.T, keys ...*Key) *testIdP {
+	t.Helper()
+	idp := &testIdP{keys: keys}
+	idp.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&idp.fetches, 1)
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		if idp.status != 0 {
+			w.WriteHeader(idp.status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(PublicJwks(idp.keys))
+	}))
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// publish replaces the published keys and the status served.
+func (idp *testIdP) publish(status int, keys ...*Key) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.status = status
+	if keys != nil {
+		idp.keys = keys
+	}
+}
+
+func (idp *testIdP) fetchCount() int {
+	return int(atomic.LoadInt32(&idp.fetches))
+}
+
+func generateKeys(t *testing.T, algorithms ...string) []*Key {
+	t.Helper()
+	keys := make([]*Key, len(algorithms))
+	for i, alg := range algorithms {
+		key, err := Generate(alg, "k"+alg)
+		require.NoError(t, err)
+		keys[i] = key
+	}
+	return keys
+}
+
+func kids(keys []*Key) []string {
+	ids := make([]string, len(keys))
+	for i, k := range keys {
+		ids[i] = k.Id
+	}
+	return ids
+}
+
+// expireRateLimit lets the next unknown kid trigger a refetch.
+func expireRateLimit(r *RemoteJwks) {
+	r.mu.Lock()
+	r.lastAttempt = time.Now().Add(-r.minRefetch)
+	r.mu.Unlock()
+}
+
+func TestRemoteJwksInitialFetch(t *testing.T) {
+	keys := generateKeys(t, "ES256", "RS256")
+	encryption, err := newKey("kenc", "", UseEncryption, keys[1].Private)
+	require.NoError(t, err)
+	idp := newTestIdP(t, keys[0], keys[1], encryption)
+	r := NewRemoteJwks(idp.server.URL, nil, time.Hour, time.Minute, time.Hour)
+
+	// nothing is fetched until the keys are needed
+	assert.Empty(t, r.Keys())
+	assert.Equal(t, 0, idp.fetchCount())
+
+	key, err := r.VerificationKey("kES256")
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", key.Algorithm)
+	assert.Nil(t, key.Private)
+	assert.Equal(t, 1, idp.fetchCount())
+	// only signature keys are kept
+	assert.Equal(t, []string{"kES256", "kRS256"}, kids(r.Keys()))
+
+	_, err = r.SigningKey()
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	require.NoError(t, r.Refresh(context.Background()))
+	assert.Equal(t, 2, idp.fetchCount())
+}
+
+func TestRemoteJwksBackgroundRefresh(t *testing.T) {
+	keys := generateKeys(t, "ES256", "ES384")
+	idp := newTestIdP(t, keys[0])
+	r := NewRemoteJwks(idp.server.URL, nil, 20*time.Millisecond, time.Hour, time.Hour)
+	require.NoError(t, r.Refresh(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	logger.Init(ctx, false, false)
+	done := make(chan struct{})
+	concurrency.GlobalWaitGroup.Add(1)
+	go func() {
+		r.Watch(ctx)
+		close(done)
+	}()
+
+	// the new key is picked up without any lookup triggering a refetch
+	idp.publish(0, keys[1])
+	assert.Eventually(t, func() bool {
+		ids := kids(r.Keys())
+		return len(ids) == 1 && ids[0] == "kES384"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not terminate")
+	}
+}
+
+func TestRemoteJwksRateLimitsRefetch(t *testing.T) {
+	keys := generateKeys(t, "ES256")
+	idp := newTestIdP(t, keys...)
+	r := NewRemoteJwks(idp.server.URL, nil, time.Hour, time.Minute, time.Hour)
+	require.NoError(t, r.Refresh(context.Background()))
+	expireRateLimit(r)
+
+	// the first unknown kid refetches, the following ones within minRefetch do not
+	_, err := r.VerificationKey("unknown")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, 2, idp.fetchCount())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.VerificationKey("unknown")
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, idp.fetchCount())
+
+	// known kids are served from the cache
+	_, err = r.VerificationKey("kES256")
+	require.NoError(t, err)
+	assert.Equal(t, 2, idp.fetchCount())
+
+	expireRateLimit(r)
+	_, err = r.VerificationKey("unknown")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, 3, idp.fetchCount())
+}
+
+func TestRemoteJwksStaleIfError(t *testing.T) {
+	keys := generateKeys(t, "ES256")
+	idp := newTestIdP(t, keys...)
+	r := NewRemoteJwks(idp.server.URL, nil, time.Hour, time.Minute, 30*time.Minute)
+	require.NoError(t, r.Refresh(context.Background()))
+
+	// a failing refresh keeps the cached keys
+	idp.publish(http.StatusInternalServerError)
+	assert.Error(t, r.Refresh(context.Background()))
+	r.mu.Lock()
+	r.fetchedAt = time.Now().Add(-time.Hour - 29*time.Minute)
+	r.mu.Unlock()
+	_, err := r.VerificationKey("kES256")
+	require.NoError(t, err)
+	assert.Len(t, r.Keys(), 1)
+
+	// past refresh and staleIfError the keys are no longer served
+	r.mu.Lock()
+	r.fetchedAt = time.Now().Add(-time.Hour - 30*time.Minute)
+	r.mu.Unlock()
+	assert.Empty(t, r.Keys())
+	expireRateLimit(r)
+	_, err = r.VerificationKey("kES256")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	// until the provider recovers
+	idp.publish(0)
+	expireRateLimit(r)
+	_, err = r.VerificationKey("kES256")
+	require.NoError(t, err)
+	assert.Len(t, r.Keys(), 1)
+}
+
+func TestRemoteJwksKeyRotation(t *testing.T) {
+	keys := generateKeys(t, "RS256", "PS256")
+	idp := newTestIdP(t, keys[0])
+	r := NewRemoteJwks(idp.server.URL, nil, time.Hour, time.Minute, time.Hour)
+	require.NoError(t, r.Refresh(context.Background()))
+	expireRateLimit(r)
+
+	// the provider rotates to a new key, the first token it signs fetches it
+	idp.publish(0, keys[1])
+	key, err := r.VerificationKey("kPS256")
+	require.NoError(t, err)
+	assert.Equal(t, "PS256", key.Algorithm)
+
+	// and the retired key is dropped with the document that no longer lists it
+	_, err = r.VerificationKey("kRS256")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, []string{"kPS256"}, kids(r.Keys()))
+}
+
+func TestRemoteJwksSkipsUnusableKeys(t *testing.T) {
+	logger.Init(context.Background(), false, false)
+	key, err := Generate("ES256", "k1")
+	require.NoError(t, err)
+	encryption, err := newKey("kenc", "", UseEncryption, key.Private)
+	require.NoError(t, err)
+	var document atomic.Value
+	document.Store(mixedJwks(t, key))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(document.Load().([]byte))
+	}))
+	t.Cleanup(server.Close)
+	r := NewRemoteJwks(server.URL, nil, time.Hour, 0, time.Hour)
+
+	// the keys the provider publishes for other purposes do not keep the usable ones out of the cache
+	verification, err := r.VerificationKey("k1")
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", verification.Algorithm)
+
+	// a document left without any signature key fails, the cached keys are served meanwhile
+	data, err := json.Marshal(PublicJwks([]*Key{encryption}))
+	require.NoError(t, err)
+	document.Store(data)
+	assert.Error(t, r.Refresh(context.Background()))
+	assert.Equal(t, []string{"k1"}, kids(r.Keys()))
+}
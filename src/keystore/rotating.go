@@ -0,0 +1,270 @@
+package keystore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// rotationCheckInterval how often the rotation schedule is evaluated
+const rotationCheckInterval = time.Minute
+
+// RotatingKeyStore is a KeyStore generating its own signing keys and rotating them on a schedule.
+//
+// The active key signs for period, then it is retired and a new key takes over. Retired keys are kept for
+// verification during grace and pruned afterwards. Key material is persisted to a state file so keys survive
+// restarts.
+type RotatingKeyStore struct {
+	algorithm string
+	period    time.Duration
+	grace     time.Duration
+	stateFile string
+
+	mu   sync.RWMutex
+	keys []*Key
+}
+
+// rotationState is the on-disk representation of the rotating key store.
+type rotationState struct {
+	Keys []rotationStateKey `json:"keys"`
+}
+
+type rotationStateKey struct {
+	Jwk       jose.JSONWebKey `json:"jwk"`
+	CreatedAt time.Time       `json:"created_at"`
+	RetiredAt time.Time       `json:"retired_at"`
+}
+
+// NewRotatingKeyStore creates a rotating key store, loading the persisted keys and rotating right away when
+// there is no active key or the active key is due.
+//
+// Parameters:
+//   - stateFile string: File the key material is persisted to
+//   - algorithm string: JWS algorithm of the generated keys
+//   - period time.Duration: How long a key signs before it is rotated
+//   - grace time.Duration: How long a retired key remains available for verification
+//
+// Returns:
+//   - *RotatingKeyStore: The key store
+//   - error: An error if the state file cannot be read, parsed or written
+func NewRotatingKeyStore(stateFile, algorithm string, period, grace time.Duration) (*RotatingKeyStore, error) {
+	s := &RotatingKeyStore{algorithm: algorithm, period: period, grace: grace, stateFile: stateFile}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if _, err := s.Rotate(context.Background(), false); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SigningKey returns the active key.
+func (s *RotatingKeyStore) SigningKey() (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if active := s.active(); active != nil {
+		return active, nil
+	}
+	return nil, ErrNoSigningKey
+}
+
+// VerificationKey returns the active or retired key registered under kid.
+func (s *RotatingKeyStore) VerificationKey(kid string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, k := range s.keys {
+		if k.Id == kid {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+}
+
+// Keys returns the active and the retired keys still in their grace period, ordered by kid.
+func (s *RotatingKeyStore) Keys() []*Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]*Key, len(s.keys))
+	copy(keys, s.keys)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Id < keys[j].Id })
+	return keys
+}
+
+// Rotate retires the active key and generates a new one when the active key is due or force is set, then
+// prunes the retired keys past their grace period. The new state is persisted before it is served.
+//
+// Returns:
+//   - bool: True if a new key was generated
+//   - error: An error if the key cannot be generated or the state cannot be persisted
+func (s *RotatingKeyStore) Rotate(ctx context.Context, force bool) (bool, error) {
+	log := logger.SugaredLogger().With("package", "keystore", "action", "Rotate")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	active := s.active()
+	due := force || active == nil || !now.Before(active.CreatedAt.Add(s.period))
+
+	keys := make([]*Key, 0, len(s.keys)+1)
+	pruned := 0
+	for _, k := range s.keys {
+		if !k.RetiredAt.IsZero() && !now.Before(k.RetiredAt.Add(s.grace)) {
+			pruned++
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if !due && pruned == 0 {
+		return false, nil
+	}
+
+	_, span := tracer.Start(ctx, "Signing key rotation")
+	defer span.End()
+
+	var next *Key
+	if due {
+		var err error
+		if next, err = Generate(s.algorithm, ""); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			span.RecordError(err)
+			return false, err
+		}
+		next.CreatedAt = now
+		if active != nil {
+			// retire a copy so readers holding the active key never see it change
+			retired := *active
+			retired.RetiredAt = now
+			for i, k := range keys {
+				if k == active {
+					keys[i] = &retired
+				}
+			}
+		}
+		keys = append(keys, next)
+	}
+	if err := s.persist(keys); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return false, err
+	}
+	s.keys = keys
+
+	span.SetAttributes(attribute.Int("keystore.pruned", pruned))
+	if pruned > 0 {
+		log.Infof("pruned %d retired signing keys past their grace period", pruned)
+	}
+	if next != nil {
+		span.SetAttributes(attribute.String("keystore.kid", next.Id), attribute.String("keystore.alg", next.Algorithm))
+		if active != nil {
+			span.SetAttributes(attribute.String("keystore.retired_kid", active.Id))
+			log.Infof("rotated signing key %s to %s, %s remains valid for verification until %s",
+				active.Id, next.Id, active.Id, now.Add(s.grace).UTC().Format(time.RFC3339))
+		} else {
+			log.Infof("generated signing key %s (%s)", next.Id, next.Algorithm)
+		}
+	}
+	return next != nil, nil
+}
+
+// Watch evaluates the rotation schedule until ctx is cancelled.
+func (s *RotatingKeyStore) Watch(ctx context.Context) {
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().With("package", "keystore", "action", "RotationWatch")
+	ticker := time.NewTicker(rotationCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debugf("key rotation watcher terminated")
+			return
+		case <-ticker.C:
+			if _, err := s.Rotate(ctx, false); err != nil {
+				log.Errorf("error rotating signing key, keeping the current key: %s", err.Error())
+			}
+		}
+	}
+}
+
+// active returns the newest key that is not retired. Callers must hold mu.
+func (s *RotatingKeyStore) active() *Key {
+	var active *Key
+	for _, k := range s.keys {
+		if k.RetiredAt.IsZero() && (active == nil || k.CreatedAt.After(active.CreatedAt)) {
+			active = k
+		}
+	}
+	return active
+}
+
+// load reads the persisted keys, a missing state file is an empty store.
+func (s *RotatingKeyStore) load() error {
+	data, err := ioutil.ReadFile(s.stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading key rotation state %s: %s", s.stateFile, err.Error())
+	}
+	var state rotationState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("error parsing key rotation state %s: %s", s.stateFile, err.Error())
+	}
+	keys := make([]*Key, 0, len(state.Keys))
+	for _, sk := range state.Keys {
+		k, err := FromJwk(sk.Jwk)
+		if err != nil {
+			return fmt.Errorf("error parsing key rotation state %s: %s", s.stateFile, err.Error())
+		}
+		k.CreatedAt, k.RetiredAt = sk.CreatedAt, sk.RetiredAt
+		keys = append(keys, k)
+	}
+	s.keys = keys
+	return nil
+}
+
+// persist atomically writes the keys, including their private material, to the state file.
+func (s *RotatingKeyStore) persist(keys []*Key) error {
+	state := rotationState{Keys: make([]rotationStateKey, 0, len(keys))}
+	for _, k := range keys {
+		state.Keys = append(state.Keys, rotationStateKey{
+			Jwk:       jose.JSONWebKey{Key: k.Private, KeyID: k.Id, Algorithm: k.Algorithm, Use: k.Use},
+			CreatedAt: k.CreatedAt,
+			RetiredAt: k.RetiredAt,
+		})
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.stateFile), filepath.Base(s.stateFile)+".*")
+	if err != nil {
+		return fmt.Errorf("error writing key rotation state %s: %s", s.stateFile, err.Error())
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.stateFile)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing key rotation state %s: %s", s.stateFile, err.Error())
+	}
+	return nil
+}
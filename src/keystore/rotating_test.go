@@ -0,0 +1,133 @@
+package keystore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRotatingKeyStore(t *testing.T, stateFile string, period, grace time.Duration) *RotatingKeyStore {
+	t.Helper()
+	logger.Init(context.Background(), false, false)
+	s, err := NewRotatingKeyStore(stateFile, "ES256", period, grace)
+	require.NoError(t, err)
+	return s
+}
+
+func TestRotatingKeyStoreGeneratesAndPersistsKeys(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "keys.json")
+	s := newRotatingKeyStore(t, stateFile, time.Hour, time.Hour)
+
+	active, err := s.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", active.Algorithm)
+	assert.True(t, active.CanSign())
+	assert.False(t, active.CreatedAt.IsZero())
+	assert.Len(t, s.Keys(), 1)
+
+	// the private material is persisted for the owner only
+	info, err := os.Stat(stateFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// a restart within the period keeps signing with the same key
+	restarted := newRotatingKeyStore(t, stateFile, time.Hour, time.Hour)
+	reloaded, err := restarted.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, active.Id, reloaded.Id)
+	assert.Equal(t, active.Public, reloaded.Public)
+	assert.True(t, active.CreatedAt.Equal(reloaded.CreatedAt))
+}
+
+func TestRotatingKeyStoreRotates(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "keys.json")
+	s := newRotatingKeyStore(t, stateFile, time.Hour, time.Hour)
+	first, err := s.SigningKey()
+	require.NoError(t, err)
+
+	// the active key is not due yet
+	rotated, err := s.Rotate(context.Background(), false)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	rotated, err = s.Rotate(context.Background(), true)
+	require.NoError(t, err)
+	assert.True(t, rotated)
+	second, err := s.SigningKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Id, second.Id)
+	// the key held by a reader is never changed, the store retires a copy
+	assert.True(t, first.RetiredAt.IsZero())
+
+	// the retired key still verifies during its grace period, and survives a restart
+	retired, err := s.VerificationKey(first.Id)
+	require.NoError(t, err)
+	assert.False(t, retired.RetiredAt.IsZero())
+	assert.Len(t, s.Keys(), 2)
+	restarted := newRotatingKeyStore(t, stateFile, time.Hour, time.Hour)
+	active, err := restarted.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, second.Id, active.Id)
+	_, err = restarted.VerificationKey(first.Id)
+	assert.NoError(t, err)
+}
+
+func TestRotatingKeyStoreRotatesDueKeys(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "keys.json")
+	s := newRotatingKeyStore(t, stateFile, time.Hour, time.Hour)
+	first, err := s.SigningKey()
+	require.NoError(t, err)
+
+	// a restart past the period rotates right away
+	restarted := newRotatingKeyStore(t, stateFile, time.Nanosecond, time.Hour)
+	active, err := restarted.SigningKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Id, active.Id)
+	assert.Len(t, restarted.Keys(), 2)
+}
+
+func TestRotatingKeyStorePrunesRetiredKeys(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "keys.json")
+	s := newRotatingKeyStore(t, stateFile, time.Hour, 0)
+	first, err := s.SigningKey()
+	require.NoError(t, err)
+
+	_, err = s.Rotate(context.Background(), true)
+	require.NoError(t, err)
+	second, err := s.SigningKey()
+	require.NoError(t, err)
+
+	// the retired key is past its grace period at the next evaluation of the schedule
+	rotated, err := s.Rotate(context.Background(), false)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+	_, err = s.VerificationKey(first.Id)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	keys := s.Keys()
+	require.Len(t, keys, 1)
+	assert.Equal(t, second.Id, keys[0].Id)
+
+	// the pruning is persisted
+	restarted := newRotatingKeyStore(t, stateFile, time.Hour, 0)
+	assert.Len(t, restarted.Keys(), 1)
+}
+
+func TestRotatingKeyStoreRejectsInvalidState(t *testing.T) {
+	logger.Init(context.Background(), false, false)
+	stateFile := filepath.Join(t.TempDir(), "keys.json")
+	for name, content := range map[string]string{
+		"not json":    `{"keys": [`,
+		"invalid jwk": `{"keys": [{"jwk": {"kty": "EC", "kid": "k1"}}]}`,
+	} {
+		require.NoError(t, ioutil.WriteFile(stateFile, []byte(content), 0600))
+		_, err := NewRotatingKeyStore(stateFile, "ES256", time.Hour, time.Hour)
+		assert.Error(t, err, name)
+	}
+}
@@ -10,6 +10,14 @@ import (
 	"jwt-sign/api"
 	"jwt-sign/configuration"
 	"jwt-sign/docs"
+	"jwt-sign/keystore"
+	"jwt-sign/privacy"
+	"jwt-sign/questionnaire"
+	"jwt-sign/replay"
+	"jwt-sign/revocation"
+	"jwt-sign/signer"
+	"jwt-sign/store"
+	"jwt-sign/token"
 
 	"dev.azure.com/coderollers/almeria/go-shared-noversion/tracer"
 	"github.com/danbordeanu/go-logger"
@@ -41,12 +49,13 @@ func main() {
 	pflag.BoolVarP(&appConfig.VaultLogging, "vault-logging", "v", false, "Configure the Vault API Client internal logger. Do not use this in Production!")
 	pflag.BoolVarP(&appConfig.GinLogger, "gin-logger", "g", false, "Activate Gin's logger, for debugging. Do not use this in Production!")
 	pflag.StringVarP(&appConfig.UseTelemetry, "telemetry", "r", "", "Activate telemetry local or remote/jaeger")
+	pflag.StringVarP(&appConfig.KeyStoreDir, "keystore", "k", appConfig.KeyStoreDir, "Directory holding the PEM and JWK/JWKS signing keys")
 	pflag.Parse()
 
 	// Initialize main context and set up cancellation token for SIGINT/SIGQUIT
 	ctx = context.Background()
 	ctx, cancel = context.WithCancel(ctx)
-	cSignal := make(chan os.Signal)
+	cSignal := make(chan os.Signal, 1)
 	signal.Notify(cSignal, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 
 	// Initialize logger
@@ -106,6 +115,42 @@ func main() {
 		}
 	}
 
+	// Signing keys
+	if err = keystore.Init(ctx, appConfig); err != nil {
+		log.Fatalf("unable to initialize key store: %s", err.Error())
+	}
+	if err = signer.Init(appConfig, keystore.Default()); err != nil {
+		log.Fatalf("unable to initialize answers signer: %s", err.Error())
+	}
+
+	// Token revocation
+	if err = revocation.Init(ctx, appConfig); err != nil {
+		log.Fatalf("unable to initialize revocation store: %s", err.Error())
+	}
+
+	// Token verification keys
+	if err = token.Init(ctx, appConfig, keystore.Default(), revocation.Default()); err != nil {
+		log.Fatalf("unable to initialize token verifier: %s", err.Error())
+	}
+
+	// Onboarding records
+	if err = store.Init(appConfig); err != nil {
+		log.Fatalf("unable to initialize onboarding store: %s", err.Error())
+	}
+	if err = privacy.Init(appConfig); err != nil {
+		log.Fatalf("unable to initialize answers hasher: %s", err.Error())
+	}
+
+	// Questionnaires
+	if err = questionnaire.Init(appConfig); err != nil {
+		log.Fatalf("unable to load questionnaires: %s", err.Error())
+	}
+
+	// Replay protection
+	if err = replay.Init(ctx, appConfig); err != nil {
+		log.Fatalf("unable to initialize nonce cache: %s", err.Error())
+	}
+
 	// Trigger context cancellation token on SIGINT/SIGTERM
 	go func() {
 		<-cSignal
@@ -138,6 +183,18 @@ func main() {
 			}()
 		}
 		concurrency.GlobalWaitGroup.Wait()
+		// stores are closed once no handler can use them anymore
+		if err = revocation.Default().Close(); err != nil {
+			log.Errorf("error closing revocation store: %v", err)
+		}
+		if err = store.Default().Close(); err != nil {
+			log.Errorf("error closing onboarding store: %v", err)
+		}
+		if nonces := replay.Default(); nonces != nil {
+			if err = nonces.Close(); err != nil {
+				log.Errorf("error closing nonce cache: %v", err)
+			}
+		}
 		log.Infof("cleanup done.")
 		cancel()
 	}()
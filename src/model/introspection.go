@@ -0,0 +1,43 @@
+package model
+
+import (
+	"fmt"
+)
+
+// Introspection represents an RFC 7662 token introspection request.
+//
+// swagger:model
+type Introspection struct {
+	Request       `json:"-" form:"-" swaggerignore:"true"`
+	Token         string `json:"token" form:"token" example:"your_jwt_here"`
+	TokenTypeHint string `json:"token_type_hint" form:"token_type_hint" example:"access_token"`
+}
+
+// Validate checks if the required fields in Introspection are present.
+//
+// Returns:
+//   - error: Validation error, nil if validation passes
+func (r *Introspection) Validate() error {
+	if r.Token == "" {
+		return fmt.Errorf("missing parameter: token")
+	}
+	return nil
+}
+
+// IntrospectionResult represents an RFC 7662 token introspection response. Only active is set for inactive tokens.
+//
+// swagger:model
+type IntrospectionResult struct {
+	Active    bool        `json:"active" example:"true"`
+	Scope     string      `json:"scope,omitempty" example:"read write"`
+	ClientId  string      `json:"client_id,omitempty" example:"gateway"`
+	Username  string      `json:"username,omitempty" example:"JonnyBoy"`
+	TokenType string      `json:"token_type,omitempty" example:"Bearer"`
+	Exp       int64       `json:"exp,omitempty" example:"1700000900"`
+	Iat       int64       `json:"iat,omitempty" example:"1700000000"`
+	Nbf       int64       `json:"nbf,omitempty" example:"1700000000"`
+	Sub       string      `json:"sub,omitempty" example:"JonnyBoy"`
+	Aud       interface{} `json:"aud,omitempty" swaggertype:"array,string" example:"jwt-sign"`
+	Iss       string      `json:"iss,omitempty" example:"https://idp.example.com"`
+	Jti       string      `json:"jti,omitempty" example:"0b8f7c8e-4e4f-4a3c-9c1e-1f0f6f3c2a11"`
+}
@@ -0,0 +1,19 @@
+package model
+
+// FieldError describes why one field of a request was rejected.
+//
+// swagger:model
+type FieldError struct {
+	Field      string `json:"field" example:"answers[1]"`
+	QuestionId string `json:"questionId,omitempty" example:"country"`
+	Code       string `json:"code" example:"not_allowed"`
+	Message    string `json:"message" example:"answer must be one of DE, FR, RO"`
+}
+
+// ValidationFailure is the data of a failure result rejecting some fields of the request.
+//
+// swagger:model
+type ValidationFailure struct {
+	ErrorCode string       `json:"errorCode" example:"answers_invalid"`
+	Fields    []FieldError `json:"fields"`
+}
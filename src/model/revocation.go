@@ -0,0 +1,25 @@
+package model
+
+import (
+	"fmt"
+)
+
+// Revocation represents an RFC 7009 token revocation request.
+//
+// swagger:model
+type Revocation struct {
+	Request       `json:"-" form:"-" swaggerignore:"true"`
+	Token         string `json:"token" form:"token" example:"your_jwt_here"`
+	TokenTypeHint string `json:"token_type_hint" form:"token_type_hint" example:"access_token"`
+}
+
+// Validate checks if the required fields in Revocation are present.
+//
+// Returns:
+//   - error: Validation error, nil if validation passes
+func (r *Revocation) Validate() error {
+	if r.Token == "" {
+		return fmt.Errorf("missing parameter: token")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
@@ -10,7 +11,18 @@ import (
 type SignatureValidation struct {
 	Request   `json:"-" swaggerignore:"true"`
 	User      string `json:"user" example:"JonnyBoy"`
-	Signature string `json:"signature" example:"test-signature-JonnyBoy"`
+	Signature string `json:"signature" example:"eyJhbGciOiJFUzI1NiIsInR5cCI6IkpXVCJ9.eyJzdWIiOiJKb25ueUJveSJ9.signature"`
+	// Proof of one answer, checked against the Merkle root of the signature when present
+	Proof *AnswerProof `json:"proof,omitempty"`
+}
+
+// AnswerProof represents the inclusion proof of one answer in the Merkle tree whose root a signature carries.
+//
+// swagger:model
+type AnswerProof struct {
+	Index int      `json:"index" example:"0"`
+	Leaf  string   `json:"leaf" example:"WyJzYWx0Iix7InEiOiJxdWVzdGlvbjEiLCJhIjoiYW5zd2VyMSJ9XQ"`
+	Path  []string `json:"path"`
 }
 
 // Validate checks if the required fields in SignatureValidation are present.
@@ -24,6 +36,14 @@ func (r *SignatureValidation) Validate() error {
 	if r.Signature == "" {
 		return fmt.Errorf("missing parameter: signature")
 	}
+	if r.Proof != nil {
+		if r.Proof.Leaf == "" {
+			return fmt.Errorf("missing parameter: proof.leaf")
+		}
+		if r.Proof.Index < 0 {
+			return fmt.Errorf("invalid parameter: proof.index must be non-negative")
+		}
+	}
 	return nil
 }
 
@@ -35,6 +55,10 @@ type JwtValidation struct {
 	Jwt       string   `json:"jwt" example:"your_jwt_here"`
 	Questions []string `json:"questions" example:"question1,question2"`
 	Answers   []string `json:"answers" example:"answer1,answer2"`
+	// EncryptTo is the public JWK of the recipient the signature is encrypted to, the signature is returned in clear when empty
+	EncryptTo json.RawMessage `json:"encryptTo,omitempty" swaggertype:"object"`
+	// Format of the signature, the format configured for the issuer of the jwt when empty
+	Format string `json:"format,omitempty" example:"jwt" enums:"jwt,v4.public,v4.local,sd-jwt,merkle"`
 }
 
 // Validate checks if the required fields in JwtValidation are present.
@@ -53,3 +77,192 @@ func (r *JwtValidation) Validate() error {
 	}
 	return nil
 }
+
+// AnswerValue is an answer of any of the supported JSON types: a string, a number, a boolean or an array of strings
+// for multiple choices.
+type AnswerValue struct {
+	// Value is a string, a float64, a bool or a []string
+	Value interface{}
+}
+
+// UnmarshalJSON accepts the supported JSON types only.
+func (v *AnswerValue) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch value := raw.(type) {
+	case string, float64, bool:
+		v.Value = value
+	case []interface{}:
+		choices := make([]string, len(value))
+		for i, choice := range value {
+			s, ok := choice.(string)
+			if !ok {
+				return fmt.Errorf("multiple choice answers must be strings")
+			}
+			choices[i] = s
+		}
+		v.Value = choices
+	case nil:
+		v.Value = nil
+	default:
+		return fmt.Errorf("answers must be a string, a number, a boolean or an array of strings")
+	}
+	return nil
+}
+
+// MarshalJSON encodes the answer with its JSON type.
+func (v AnswerValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Value)
+}
+
+// AnswerItem represents the answer to one question.
+//
+// swagger:model
+type AnswerItem struct {
+	QuestionId string      `json:"questionId" example:"country"`
+	Answer     AnswerValue `json:"answer" swaggertype:"string" example:"RO"`
+}
+
+// JwtValidationV2 represents the structure for validating JWTs with structured answers.
+//
+// swagger:model
+type JwtValidationV2 struct {
+	Request `json:"-" swaggerignore:"true"`
+	Jwt     string       `json:"jwt" example:"your_jwt_here"`
+	Answers []AnswerItem `json:"answers"`
+	// EncryptTo is the public JWK of the recipient the signature is encrypted to, the signature is returned in clear when empty
+	EncryptTo json.RawMessage `json:"encryptTo,omitempty" swaggertype:"object"`
+	// Format of the signature, the format configured for the issuer of the jwt when empty
+	Format string `json:"format,omitempty" example:"jwt" enums:"jwt,v4.public,v4.local,sd-jwt,merkle"`
+}
+
+// Validate checks if the required fields in JwtValidationV2 are present.
+//
+// Returns:
+//   - error: Validation error, nil if validation passes
+func (r *JwtValidationV2) Validate() error {
+	if r.Jwt == "" {
+		return fmt.Errorf("missing parameter: jwt")
+	}
+	if len(r.Answers) == 0 {
+		return fmt.Errorf("missing parameter: answers")
+	}
+	seen := map[string]bool{}
+	for i, item := range r.Answers {
+		if item.QuestionId == "" {
+			return fmt.Errorf("missing parameter: answers[%d].questionId", i)
+		}
+		if item.Answer.Value == nil {
+			return fmt.Errorf("missing parameter: answers[%d].answer", i)
+		}
+		if seen[item.QuestionId] {
+			return fmt.Errorf("invalid parameter: question %s is answered more than once", item.QuestionId)
+		}
+		seen[item.QuestionId] = true
+	}
+	return nil
+}
+
+// AnswersClaims represents the claims carried by an answers signature.
+//
+// swagger:model
+type AnswersClaims struct {
+	Id               string `json:"jti" example:"5f0c6e4e-8a0e-4f43-9d0e-3c1f1b0e8f1a"`
+	Subject          string `json:"sub" example:"JonnyBoy"`
+	Issuer           string `json:"iss" example:"jwt-sign"`
+	IssuedAt         int64  `json:"iat" example:"1700000000"`
+	AnswersDigest    string `json:"answers_digest,omitempty" example:"ZnKHtorc20MbpdAlIRspvKpkAVnDOTIynN3lQ1M8Myg"`
+	AnswersDigestAlg string `json:"answers_digest_alg,omitempty" example:"sha-256"`
+	AnswersFormat    string `json:"answers_format,omitempty" example:"structured"`
+	// SdAlg and Answers replace the answers digest in SD-JWT signatures, Answers lists the digests of the disclosures
+	SdAlg   string             `json:"_sd_alg,omitempty" example:"sha-256"`
+	Answers []DisclosureDigest `json:"answers,omitempty"`
+	// AnswersRoot replaces the answers digest in Merkle signatures, the root of the tree over AnswersCount answers
+	AnswersRoot    string `json:"answers_root,omitempty" example:"2zx8gX08BhpsQo7lvIU_Aaq45cAbhme8vnjP2ZoKW7I"`
+	AnswersRootAlg string `json:"answers_root_alg,omitempty" example:"sha-256"`
+	AnswersCount   int    `json:"answers_count,omitempty" example:"3"`
+}
+
+// DisclosureDigest represents an element of the answers claim of an SD-JWT, the digest of the disclosure of one answer.
+//
+// swagger:model
+type DisclosureDigest struct {
+	Digest string `json:"..." example:"X9yH0Ajrdm1Oij4tWso9UzzKJvPoDxwmuEcO3XAdRC0"`
+}
+
+// AnswersSignature represents the signature produced over the answers of a validated JWT.
+//
+// swagger:model
+type AnswersSignature struct {
+	Status    string        `json:"status" example:"successfully"`
+	Signature string        `json:"signature" example:"eyJhbGciOiJFUzI1NiIsImtpZCI6ImsxIiwidHlwIjoiSldUIn0.eyJzdWIiOiJKb25ueUJveSJ9.signature"`
+	KeyId     string        `json:"kid" example:"k1"`
+	Algorithm string        `json:"alg" example:"ES256"`
+	Claims    AnswersClaims `json:"claims"`
+	// Encrypted is set when the signature is a compact JWE nesting the JWS, encrypted to the requested recipient
+	Encrypted bool `json:"encrypted,omitempty" example:"false"`
+	// Disclosures of the answers of an SD-JWT signature, which already ends with all of them
+	Disclosures []string `json:"disclosures,omitempty"`
+	// Proofs of inclusion of every answer in the Merkle root of the signature
+	Proofs []AnswerProof `json:"proofs,omitempty"`
+}
+
+// SignatureVerification represents the result of a successful signature verification.
+//
+// swagger:model
+type SignatureVerification struct {
+	Status    string        `json:"status" example:"successfully"`
+	User      string        `json:"user" example:"JonnyBoy"`
+	KeyId     string        `json:"kid" example:"k1"`
+	Algorithm string        `json:"alg" example:"ES256"`
+	Claims    AnswersClaims `json:"claims"`
+	// Disclosed is the answer proven by the proof of the request
+	Disclosed *DisclosedAnswer `json:"disclosed,omitempty"`
+}
+
+// PresentationValidation represents the structure for verifying an SD-JWT presentation of signed answers.
+//
+// swagger:model
+type PresentationValidation struct {
+	Request      `json:"-" swaggerignore:"true"`
+	User         string `json:"user" example:"JonnyBoy"`
+	Presentation string `json:"presentation" example:"eyJhbGciOiJFUzI1NiIsImtpZCI6ImsxIiwidHlwIjoiSldUIn0.eyJzdWIiOiJKb25ueUJveSJ9.signature~WyJzYWx0Iix7InEiOiJxdWVzdGlvbjEiLCJhIjoiYW5zd2VyMSJ9XQ~"`
+}
+
+// Validate checks if the required fields in PresentationValidation are present.
+//
+// Returns:
+//   - error: Validation error, nil if validation passes
+func (r *PresentationValidation) Validate() error {
+	if r.User == "" {
+		return fmt.Errorf("missing parameter: user")
+	}
+	if r.Presentation == "" {
+		return fmt.Errorf("missing parameter: presentation")
+	}
+	return nil
+}
+
+// DisclosedAnswer represents an answer revealed by a presentation, with its question for question/answer pairs or its
+// question id for structured answers.
+//
+// swagger:model
+type DisclosedAnswer struct {
+	Question   string      `json:"question,omitempty" example:"question1"`
+	QuestionId string      `json:"questionId,omitempty" example:"country"`
+	Answer     AnswerValue `json:"answer" swaggertype:"string" example:"RO"`
+}
+
+// PresentationVerification represents the result of a successful presentation verification.
+//
+// swagger:model
+type PresentationVerification struct {
+	Status    string            `json:"status" example:"successfully"`
+	User      string            `json:"user" example:"JonnyBoy"`
+	KeyId     string            `json:"kid" example:"k1"`
+	Algorithm string            `json:"alg" example:"ES256"`
+	Claims    AnswersClaims     `json:"claims"`
+	Disclosed []DisclosedAnswer `json:"disclosed"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"fmt"
+)
+
+// TokenIssuance represents the structure for requesting a new token.
+//
+// swagger:model
+type TokenIssuance struct {
+	Request  `json:"-" swaggerignore:"true"`
+	Subject  string                 `json:"subject" example:"JonnyBoy"`
+	Audience []string               `json:"audience" example:"jwt-sign"`
+	Claims   map[string]interface{} `json:"claims"`
+	TtlSec   int64                  `json:"ttl" example:"900"`
+	Format   string                 `json:"format,omitempty" example:"jwt" enums:"jwt,v4.public,v4.local"`
+}
+
+// Validate checks if the required fields in TokenIssuance are present.
+//
+// Returns:
+//   - error: Validation error, nil if validation passes
+func (r *TokenIssuance) Validate() error {
+	if r.Subject == "" {
+		return fmt.Errorf("missing parameter: subject")
+	}
+	if r.TtlSec < 0 {
+		return fmt.Errorf("invalid parameter: ttl must not be negative")
+	}
+	return nil
+}
+
+// IssuedToken represents a token minted by the service.
+//
+// swagger:model
+type IssuedToken struct {
+	Token     string `json:"token" example:"eyJhbGciOiJFUzI1NiIsImtpZCI6ImsxIiwidHlwIjoiSldUIn0.eyJzdWIiOiJKb25ueUJveSJ9.signature"`
+	TokenType string `json:"token_type" example:"Bearer"`
+	ExpiresIn int64  `json:"expires_in" example:"900"`
+	ExpiresAt int64  `json:"expires_at" example:"1700000000"`
+	KeyId     string `json:"kid" example:"k1"`
+	Algorithm string `json:"alg" example:"ES256"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenIssuanceValidate(t *testing.T) {
+	assert.NoError(t, (&TokenIssuance{Subject: "JonnyBoy", TtlSec: 60}).Validate())
+	assert.NoError(t, (&TokenIssuance{Subject: "JonnyBoy"}).Validate())
+	assert.Error(t, (&TokenIssuance{TtlSec: 60}).Validate())
+	assert.EqualError(t, (&TokenIssuance{Subject: "JonnyBoy", TtlSec: -1}).Validate(), "invalid parameter: ttl must not be negative")
+}
+
+func TestJwtValidationValidate(t *testing.T) {
+	assert.NoError(t, (&JwtValidation{Jwt: "jwt", Questions: []string{"q"}, Answers: []string{"a"}}).Validate())
+	// the signer decides which formats exist, and the questionnaire whether the answers pair up
+	assert.NoError(t, (&JwtValidation{Jwt: "jwt", Questions: []string{"q"}, Answers: []string{"a"}, Format: "xml"}).Validate())
+	assert.NoError(t, (&JwtValidation{Jwt: "jwt", Questions: []string{"q", "r"}, Answers: []string{"a"}}).Validate())
+	for name, r := range map[string]JwtValidation{
+		"missing jwt":       {Questions: []string{"q"}, Answers: []string{"a"}},
+		"missing questions": {Jwt: "jwt", Answers: []string{"a"}},
+		"missing answers":   {Jwt: "jwt", Questions: []string{"q"}},
+	} {
+		assert.Error(t, r.Validate(), name)
+	}
+}
@@ -0,0 +1,70 @@
+package paseto
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// dateClaims are the registered claims holding a date, an RFC 3339 string in PASETO and a NumericDate in JWT.
+var dateClaims = []string{"exp", "nbf", "iat"}
+
+// KeyFooter is the JSON footer of the tokens issued by the service, naming the key that signed them.
+type KeyFooter struct {
+	KeyId string `json:"kid,omitempty"`
+}
+
+// KeyId returns the kid of a JSON footer, empty when the footer is empty or holds no kid.
+func KeyId(footer []byte) string {
+	var f KeyFooter
+	if len(footer) == 0 || json.Unmarshal(footer, &f) != nil {
+		return ""
+	}
+	return f.KeyId
+}
+
+// ToNumericDates converts the date claims of a PASETO payload to JWT NumericDates, so that the claims go through the
+// same checks as the claims of a JWT.
+//
+// Parameters:
+//   - claims map[string]interface{}: The decoded payload, modified in place
+//
+// Returns:
+//   - error: An error if a date claim is not an RFC 3339 string
+func ToNumericDates(claims map[string]interface{}) error {
+	for _, name := range dateClaims {
+		v, ok := claims[name]
+		if !ok || v == nil {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("claim %s is not an RFC 3339 date", name)
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return fmt.Errorf("claim %s is not an RFC 3339 date", name)
+		}
+		claims[name] = float64(t.Unix())
+	}
+	return nil
+}
+
+// FromNumericDates converts the date claims, given as Unix seconds, to the RFC 3339 strings of PASETO.
+//
+// Parameters:
+//   - claims map[string]interface{}: The claims, modified in place
+func FromNumericDates(claims map[string]interface{}) {
+	for _, name := range dateClaims {
+		var seconds int64
+		switch v := claims[name].(type) {
+		case int64:
+			seconds = v
+		case float64:
+			seconds = int64(v)
+		default:
+			continue
+		}
+		claims[name] = time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+	}
+}
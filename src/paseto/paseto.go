@@ -0,0 +1,279 @@
+package paseto
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+const (
+	// PurposePublic asymmetric authentication with Ed25519, the payload is signed and readable by anyone
+	PurposePublic = "v4.public"
+	// PurposeLocal symmetric authenticated encryption with XChaCha20 and BLAKE2b, the payload is confidential
+	PurposeLocal = "v4.local"
+
+	// KeyLength is the length of a v4.local key, in bytes
+	KeyLength = 32
+
+	nonceLength     = 32
+	tagLength       = 32
+	encKeyDomain    = "paseto-encryption-key"
+	authKeyDomain   = "paseto-auth-key-for-aead"
+	signatureLength = ed25519.SignatureSize
+)
+
+var (
+	// ErrMalformed the token is not a well-formed PASETO
+	ErrMalformed = errors.New("malformed paseto token")
+	// ErrUnsupported the token is of a version or purpose other than v4.public and v4.local
+	ErrUnsupported = errors.New("unsupported paseto version or purpose")
+	// ErrInvalid the signature or the authentication tag does not match the token content
+	ErrInvalid = errors.New("invalid paseto token")
+)
+
+// IsToken reports whether the token looks like a PASETO of any version, as opposed to a JOSE token.
+func IsToken(token string) bool {
+	return len(token) > 3 && token[0] == 'v' && token[1] >= '1' && token[1] <= '9' && token[2] == '.'
+}
+
+// Purpose returns the version and purpose of the token, v4.public or v4.local, empty for other tokens.
+func Purpose(token string) string {
+	for _, purpose := range []string{PurposePublic, PurposeLocal} {
+		if strings.HasPrefix(token, purpose+".") {
+			return purpose
+		}
+	}
+	return ""
+}
+
+// Footer returns the footer of the token. It is not authenticated until the token is verified or decrypted, and may
+// only be used to select the key doing so.
+func Footer(token string) ([]byte, error) {
+	_, _, footer, err := split(token, Purpose(token))
+	return footer, err
+}
+
+// ParseKey decodes a hex encoded v4.local key.
+//
+// Parameters:
+//   - s string: The hex encoded key, may be empty
+//
+// Returns:
+//   - []byte: The key, nil when s is empty
+//   - error: An error if s is not the hex encoding of a 32 bytes key
+func ParseKey(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(s)
+	if err != nil || len(key) != KeyLength {
+		return nil, fmt.Errorf("paseto local key must be %d hex encoded bytes", KeyLength)
+	}
+	return key, nil
+}
+
+// Sign produces a v4.public token.
+//
+// Parameters:
+//   - key ed25519.PrivateKey: The signing key
+//   - payload []byte: The message, a JSON object of claims
+//   - footer []byte: The footer, authenticated but not part of the payload, may be empty
+//   - implicit []byte: The implicit assertion, authenticated but not part of the token, may be empty
+//
+// Returns:
+//   - string: The token
+func Sign(key ed25519.PrivateKey, payload, footer, implicit []byte) string {
+	header := PurposePublic + "."
+	signature := ed25519.Sign(key, pae([]byte(header), payload, footer, implicit))
+	return join(header, append(append([]byte(nil), payload...), signature...), footer)
+}
+
+// Verify checks the signature of a v4.public token.
+//
+// Parameters:
+//   - key ed25519.PublicKey: The verification key
+//   - token string: The token
+//   - implicit []byte: The implicit assertion the token was signed with
+//
+// Returns:
+//   - []byte: The verified payload
+//   - error: ErrMalformed, ErrUnsupported or ErrInvalid
+func Verify(key ed25519.PublicKey, token string, implicit []byte) ([]byte, error) {
+	header, body, footer, err := split(token, PurposePublic)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < signatureLength {
+		return nil, ErrMalformed
+	}
+	payload, signature := body[:len(body)-signatureLength], body[len(body)-signatureLength:]
+	if !ed25519.Verify(key, pae([]byte(header), payload, footer, implicit), signature) {
+		return nil, ErrInvalid
+	}
+	return payload, nil
+}
+
+// Encrypt produces a v4.local token.
+//
+// Parameters:
+//   - key []byte: The 32 bytes symmetric key
+//   - payload []byte: The message, a JSON object of claims
+//   - footer []byte: The footer, authenticated but not encrypted, may be empty
+//   - implicit []byte: The implicit assertion, authenticated but not part of the token, may be empty
+//
+// Returns:
+//   - string: The token
+//   - error: An error if the key has the wrong length or no nonce could be drawn
+func Encrypt(key, payload, footer, implicit []byte) (string, error) {
+	nonce := make([]byte, nonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return encrypt(key, nonce, payload, footer, implicit)
+}
+
+// encrypt produces a v4.local token with the given nonce.
+func encrypt(key, nonce, payload, footer, implicit []byte) (string, error) {
+	if len(key) != KeyLength {
+		return "", fmt.Errorf("paseto local key must be %d bytes long", KeyLength)
+	}
+	header := PurposeLocal + "."
+	encKey, counterNonce, authKey := splitKey(key, nonce)
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, counterNonce)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := make([]byte, len(payload))
+	cipher.XORKeyStream(ciphertext, payload)
+	tag := mac(authKey, pae([]byte(header), nonce, ciphertext, footer, implicit))
+	body := make([]byte, 0, nonceLength+len(ciphertext)+tagLength)
+	body = append(append(append(body, nonce...), ciphertext...), tag...)
+	return join(header, body, footer), nil
+}
+
+// Decrypt authenticates and decrypts a v4.local token.
+//
+// Parameters:
+//   - key []byte: The 32 bytes symmetric key
+//   - token string: The token
+//   - implicit []byte: The implicit assertion the token was encrypted with
+//
+// Returns:
+//   - []byte: The decrypted payload
+//   - error: ErrMalformed, ErrUnsupported or ErrInvalid
+func Decrypt(key []byte, token string, implicit []byte) ([]byte, error) {
+	if len(key) != KeyLength {
+		return nil, fmt.Errorf("paseto local key must be %d bytes long", KeyLength)
+	}
+	header, body, footer, err := split(token, PurposeLocal)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < nonceLength+tagLength {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext, tag := body[:nonceLength], body[nonceLength:len(body)-tagLength], body[len(body)-tagLength:]
+	encKey, counterNonce, authKey := splitKey(key, nonce)
+	// the tag is checked before anything is decrypted
+	if !hmac.Equal(tag, mac(authKey, pae([]byte(header), nonce, ciphertext, footer, implicit))) {
+		return nil, ErrInvalid
+	}
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, counterNonce)
+	if err != nil {
+		return nil, err
+	}
+	payload := make([]byte, len(ciphertext))
+	cipher.XORKeyStream(payload, ciphertext)
+	return payload, nil
+}
+
+// splitKey derives the encryption key, the XChaCha20 nonce and the authentication key of a v4.local token.
+func splitKey(key, nonce []byte) ([]byte, []byte, []byte) {
+	tmp := keyedHash(56, key, []byte(encKeyDomain), nonce)
+	return tmp[:32], tmp[32:], keyedHash(32, key, []byte(authKeyDomain), nonce)
+}
+
+// mac returns the 32 bytes keyed BLAKE2b of the message.
+func mac(key, message []byte) []byte {
+	return keyedHash(tagLength, key, message)
+}
+
+// keyedHash returns the keyed BLAKE2b of the concatenated parts with the given output size.
+func keyedHash(size int, key []byte, parts ...[]byte) []byte {
+	h, err := blake2b.New(size, key)
+	if err != nil {
+		// the sizes and key lengths used by the package are always valid
+		panic(err)
+	}
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// pae is the pre-authentication encoding of the pieces: their count followed by the length and bytes of each, the
+// lengths being little endian 64 bits integers with the most significant bit cleared.
+func pae(pieces ...[]byte) []byte {
+	size := 8
+	for _, p := range pieces {
+		size += 8 + len(p)
+	}
+	out := make([]byte, 0, size)
+	out = le64(out, len(pieces))
+	for _, p := range pieces {
+		out = le64(out, len(p))
+		out = append(out, p...)
+	}
+	return out
+}
+
+func le64(out []byte, n int) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(n)&(1<<63-1))
+	return append(out, b[:]...)
+}
+
+// join serializes a token from its header, body and footer.
+func join(header string, body, footer []byte) string {
+	token := header + base64.RawURLEncoding.EncodeToString(body)
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token
+}
+
+// split parses a token of the expected purpose into its header, decoded body and decoded footer.
+func split(token, purpose string) (string, []byte, []byte, error) {
+	if purpose == "" || !strings.HasPrefix(token, purpose+".") {
+		if IsToken(token) {
+			return "", nil, nil, ErrUnsupported
+		}
+		return "", nil, nil, ErrMalformed
+	}
+	header := purpose + "."
+	parts := strings.Split(token[len(header):], ".")
+	if len(parts) > 2 {
+		return "", nil, nil, ErrMalformed
+	}
+	encoding := base64.RawURLEncoding.Strict()
+	body, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	var footer []byte
+	if len(parts) == 2 {
+		if footer, err = encoding.DecodeString(parts[1]); err != nil {
+			return "", nil, nil, ErrMalformed
+		}
+	}
+	return header, body, footer, nil
+}
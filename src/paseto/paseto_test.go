@@ -0,0 +1,236 @@
+package paseto
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors of the PASETO specification, docs/03-Implementation-Guide/Test-Vectors/v4.json
+const (
+	vectorSecretKey = "b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774" +
+		"1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2"
+	vectorLocalKey      = "707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f"
+	vectorSignedPayload = `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`
+	vectorSecretPayload = `{"data":"this is a secret message","exp":"2022-01-01T00:00:00+00:00"}`
+	vectorFooter        = `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`
+	vectorImplicit      = `{"test-vector":"4-S-3"}`
+)
+
+var publicVectors = []struct {
+	name     string
+	footer   string
+	implicit string
+	token    string
+}{
+	{
+		name: "4-S-1",
+		token: "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzd" +
+			"s8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA",
+	},
+	{
+		name:   "4-S-2",
+		footer: vectorFooter,
+		token: "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9v3Jt8mx_" +
+			"TdM2ceTGoqwrh4yDFn0XsHvvV_D0DtwQxVrJEBMl0F2caAdgnpKlt4p7xBnx1HcO-SPo8FPp214HDw.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3" +
+			"Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+	},
+	{
+		name:     "4-S-3",
+		footer:   vectorFooter,
+		implicit: vectorImplicit,
+		token: "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9NPWciuD3" +
+			"d0o5eXJXG5pJy-DiVEoyPYWs1YSTwWHNJq6DZD3je5gf-0M4JR9ipdUSJbIovzmBECeaWmaqcaP0DQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3" +
+			"Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+	},
+}
+
+// vectorLocalToken is the test vector 4-E-1, encrypted with an all zero nonce.
+const vectorLocalToken = "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfG" +
+	"o_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg"
+
+func vectorKeys(t *testing.T) (ed25519.PrivateKey, []byte) {
+	t.Helper()
+	secret, err := hex.DecodeString(vectorSecretKey)
+	require.NoError(t, err)
+	local, err := ParseKey(vectorLocalKey)
+	require.NoError(t, err)
+	return ed25519.PrivateKey(secret), local
+}
+
+// tamper flips one bit of the decoded body of the token.
+func tamper(t *testing.T, token string, offset int) string {
+	t.Helper()
+	purpose := Purpose(token)
+	parts := strings.SplitN(token[len(purpose)+1:], ".", 2)
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	body[offset] ^= 0x01
+	parts[0] = base64.RawURLEncoding.EncodeToString(body)
+	return purpose + "." + strings.Join(parts, ".")
+}
+
+// withFooter replaces the footer of the token, removing it when footer is empty.
+func withFooter(token, footer string) string {
+	if i := strings.LastIndex(token, "."); strings.Count(token, ".") == 3 {
+		token = token[:i]
+	}
+	if footer == "" {
+		return token
+	}
+	return token + "." + base64.RawURLEncoding.EncodeToString([]byte(footer))
+}
+
+func TestPublicVectors(t *testing.T) {
+	secret, _ := vectorKeys(t)
+	public := secret.Public().(ed25519.PublicKey)
+	for _, v := range publicVectors {
+		t.Run(v.name, func(t *testing.T) {
+			token := Sign(secret, []byte(vectorSignedPayload), []byte(v.footer), []byte(v.implicit))
+			assert.Equal(t, v.token, token)
+
+			payload, err := Verify(public, v.token, []byte(v.implicit))
+			require.NoError(t, err)
+			assert.Equal(t, vectorSignedPayload, string(payload))
+			footer, err := Footer(v.token)
+			require.NoError(t, err)
+			assert.Equal(t, v.footer, string(footer))
+		})
+	}
+}
+
+func TestLocalVector(t *testing.T) {
+	_, key := vectorKeys(t)
+	token, err := encrypt(key, make([]byte, nonceLength), []byte(vectorSecretPayload), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, vectorLocalToken, token)
+
+	payload, err := Decrypt(key, vectorLocalToken, nil)
+	require.NoError(t, err)
+	assert.Equal(t, vectorSecretPayload, string(payload))
+}
+
+func TestPublicRejectsTampering(t *testing.T) {
+	secret, _ := vectorKeys(t)
+	public := secret.Public().(ed25519.PublicKey)
+	v := publicVectors[2]
+
+	// a bit flipped in the payload or in the signature
+	for _, offset := range []int{0, len(vectorSignedPayload) - 1, len(vectorSignedPayload), len(vectorSignedPayload) + signatureLength - 1} {
+		_, err := Verify(public, tamper(t, v.token, offset), []byte(v.implicit))
+		assert.ErrorIs(t, err, ErrInvalid, "offset %d", offset)
+	}
+
+	for name, token := range map[string]string{
+		"other footer":   withFooter(v.token, `{"kid":"other"}`),
+		"footer removed": withFooter(v.token, ""),
+		"footer added":   withFooter(publicVectors[0].token, vectorFooter),
+	} {
+		_, err := Verify(public, token, []byte(v.implicit))
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+
+	_, err := Verify(public, v.token, []byte(`{"test-vector":"other"}`))
+	assert.ErrorIs(t, err, ErrInvalid, "implicit assertion")
+	_, err = Verify(public, v.token, nil)
+	assert.ErrorIs(t, err, ErrInvalid, "missing implicit assertion")
+
+	other, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, err = Verify(other, v.token, []byte(v.implicit))
+	assert.ErrorIs(t, err, ErrInvalid, "other key")
+}
+
+func TestLocalRejectsTampering(t *testing.T) {
+	_, key := vectorKeys(t)
+	token, err := Encrypt(key, []byte(vectorSecretPayload), []byte(vectorFooter), []byte(vectorImplicit))
+	require.NoError(t, err)
+	payload, err := Decrypt(key, token, []byte(vectorImplicit))
+	require.NoError(t, err)
+	assert.Equal(t, vectorSecretPayload, string(payload))
+
+	// a bit flipped in the nonce, the ciphertext or the tag
+	for _, offset := range []int{0, nonceLength, nonceLength + len(vectorSecretPayload), nonceLength + len(vectorSecretPayload) + tagLength - 1} {
+		_, err = Decrypt(key, tamper(t, token, offset), []byte(vectorImplicit))
+		assert.ErrorIs(t, err, ErrInvalid, "offset %d", offset)
+	}
+
+	for name, tampered := range map[string]string{
+		"other footer":   withFooter(token, `{"kid":"other"}`),
+		"footer removed": withFooter(token, ""),
+		"footer added":   withFooter(vectorLocalToken, vectorFooter),
+	} {
+		_, err = Decrypt(key, tampered, []byte(vectorImplicit))
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+
+	_, err = Decrypt(key, token, nil)
+	assert.ErrorIs(t, err, ErrInvalid, "missing implicit assertion")
+
+	other, err := ParseKey(strings.Repeat("ab", KeyLength))
+	require.NoError(t, err)
+	_, err = Decrypt(other, token, []byte(vectorImplicit))
+	assert.ErrorIs(t, err, ErrInvalid, "other key")
+}
+
+func TestRejectsMalformedAndUnsupported(t *testing.T) {
+	secret, key := vectorKeys(t)
+	public := secret.Public().(ed25519.PublicKey)
+	signed := publicVectors[1].token
+
+	// the purposes cannot be swapped
+	_, err := Decrypt(key, signed, nil)
+	assert.ErrorIs(t, err, ErrUnsupported)
+	_, err = Verify(public, vectorLocalToken, nil)
+	assert.ErrorIs(t, err, ErrUnsupported)
+	_, err = Verify(public, "v3.public."+strings.TrimPrefix(signed, PurposePublic+"."), nil)
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	for name, token := range map[string]string{
+		"jwt":         "eyJhbGciOiJIUzI1NiJ9.e30.c2ln",
+		"padding":     publicVectors[0].token + "==",
+		"extra part":  signed + ".e30",
+		"short body":  PurposePublic + "." + base64.RawURLEncoding.EncodeToString([]byte("short")),
+		"bad footer":  withFooter(publicVectors[0].token, "") + ".!!",
+		"empty token": "",
+	} {
+		_, err = Verify(public, token, nil)
+		assert.ErrorIs(t, err, ErrMalformed, name)
+	}
+	_, err = Decrypt(key, PurposeLocal+"."+base64.RawURLEncoding.EncodeToString(make([]byte, nonceLength)), nil)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := ParseKey("")
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+	for _, invalid := range []string{"zz", "7071", vectorLocalKey + "00"} {
+		_, err = ParseKey(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestNumericDates(t *testing.T) {
+	claims := map[string]interface{}{"exp": int64(1640995200), "iat": float64(1640995100), "sub": "JonnyBoy"}
+	FromNumericDates(claims)
+	assert.Equal(t, map[string]interface{}{"exp": "2022-01-01T00:00:00Z", "iat": "2021-12-31T23:58:20Z", "sub": "JonnyBoy"}, claims)
+
+	claims = map[string]interface{}{"exp": "2022-01-01T00:00:00+00:00", "nbf": "2022-01-01T01:00:00+01:00"}
+	require.NoError(t, ToNumericDates(claims))
+	assert.Equal(t, map[string]interface{}{"exp": float64(1640995200), "nbf": float64(1640995200)}, claims)
+
+	assert.Error(t, ToNumericDates(map[string]interface{}{"exp": float64(1640995200)}))
+	assert.Error(t, ToNumericDates(map[string]interface{}{"exp": "tomorrow"}))
+}
+
+func TestKeyId(t *testing.T) {
+	assert.Equal(t, "zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN", KeyId([]byte(vectorFooter)))
+	assert.Equal(t, "", KeyId(nil))
+	assert.Equal(t, "", KeyId([]byte("not json")))
+}
@@ -0,0 +1,144 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/danbordeanu/go-logger"
+	"golang.org/x/text/unicode/norm"
+	"jwt-sign/configuration"
+)
+
+const (
+	// AlgorithmHmacSha256 answers are hashed with HMAC-SHA256 keyed with the server key over the salt and the answer
+	AlgorithmHmacSha256 = "hmac-sha256"
+	// AlgorithmSha256 answers are hashed with SHA-256 over the salt and the answer
+	AlgorithmSha256 = "sha-256"
+
+	// minKeyLength is the shortest server key accepted, in bytes
+	minKeyLength = 32
+	// saltLength is the length of the per record salt, in bytes
+	saltLength = 16
+)
+
+// Hasher hashes answers before they are persisted, with a salt drawn for every record and, when configured, a server
+// key that is never stored along with the hashes.
+type Hasher struct {
+	key []byte
+}
+
+var hasher *Hasher
+
+// Init builds the package hasher from the application configuration. It must be called once at startup, before any
+// handler persists answers.
+func Init(conf *configuration.Configuration) error {
+	log := logger.SugaredLogger().With("package", "privacy", "action", "Init")
+	h, err := NewHasher([]byte(conf.AnswersHashKey))
+	if err != nil {
+		return err
+	}
+	if h.key == nil {
+		log.Warnf("no answers hash key configured, answers are hashed with a salt only")
+	}
+	hasher = h
+	return nil
+}
+
+// Default returns the package hasher initialized by Init.
+func Default() *Hasher {
+	return hasher
+}
+
+// NewHasher creates a Hasher.
+//
+// Parameters:
+//   - key []byte: Server key of the HMAC, answers are hashed with SHA-256 when empty
+//
+// Returns:
+//   - *Hasher: The hasher
+//   - error: An error if the key is too short
+func NewHasher(key []byte) (*Hasher, error) {
+	if len(key) == 0 {
+		return &Hasher{}, nil
+	}
+	if len(key) < minKeyLength {
+		return nil, fmt.Errorf("answers hash key must be at least %d bytes long", minKeyLength)
+	}
+	return &Hasher{key: append([]byte(nil), key...)}, nil
+}
+
+// Algorithm returns the name of the hash algorithm, stored along with the hashes.
+func (h *Hasher) Algorithm() string {
+	if h.key != nil {
+		return AlgorithmHmacSha256
+	}
+	return AlgorithmSha256
+}
+
+// HashAnswers draws a new salt and hashes the normalized answers with it.
+//
+// Parameters:
+//   - answers []string: The plaintext answers
+//
+// Returns:
+//   - string: The base64url encoded salt
+//   - []string: The base64url encoded hashes, in the order of the answers
+//   - error: An error if no salt could be drawn
+func (h *Hasher) HashAnswers(answers []string) (string, []string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", nil, fmt.Errorf("error drawing answers salt: %s", err.Error())
+	}
+	hashes := make([]string, len(answers))
+	for i, answer := range answers {
+		hashes[i] = h.hash(salt, answer)
+	}
+	return base64.RawURLEncoding.EncodeToString(salt), hashes, nil
+}
+
+// HashSecret hashes a secret such as a signature with the salt of a record, so that the record can tell the secret it
+// was given without keeping it. Unlike answers the secret is hashed as is.
+//
+// Parameters:
+//   - salt string: The base64url encoded salt returned by HashAnswers
+//   - secret string: The secret to hash
+//
+// Returns:
+//   - string: The base64url encoded hash
+//   - error: An error if the salt is not base64url encoded
+func (h *Hasher) HashSecret(salt string, secret string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(salt)
+	if err != nil {
+		return "", fmt.Errorf("error decoding answers salt: %s", err.Error())
+	}
+	return h.sum(decoded, secret), nil
+}
+
+// hash returns the base64url encoded hash of the salt followed by the normalized answer.
+func (h *Hasher) hash(salt []byte, answer string) string {
+	return h.sum(salt, Normalize(answer))
+}
+
+// sum returns the base64url encoded hash of the salt followed by the value.
+func (h *Hasher) sum(salt []byte, value string) string {
+	var mac hash.Hash
+	if h.key != nil {
+		mac = hmac.New(sha256.New, h.key)
+	} else {
+		mac = sha256.New()
+	}
+	mac.Write(salt)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Normalize returns the canonical form of an answer: NFC normalized, trimmed and with runs of white space collapsed
+// to a single space, so that answers differing by their encoding only hash alike.
+func Normalize(answer string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(answer)), " ")
+}
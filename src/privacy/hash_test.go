@@ -0,0 +1,94 @@
+package privacy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSalt is a fixed salt for the hashes computed independently below.
+var testSalt = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+func TestNewHasher(t *testing.T) {
+	h, err := NewHasher(nil)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmSha256, h.Algorithm())
+
+	h, err = NewHasher(bytes.Repeat([]byte("k"), minKeyLength))
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmHmacSha256, h.Algorithm())
+
+	_, err = NewHasher(bytes.Repeat([]byte("k"), minKeyLength-1))
+	assert.EqualError(t, err, "answers hash key must be at least 32 bytes long")
+}
+
+func TestHash(t *testing.T) {
+	// SHA-256 and HMAC-SHA256 of the salt followed by the answer, computed independently
+	h, err := NewHasher(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ovPbIYIy75ylEEHTgCLXSuvMi-QpDWIL8ozIUtOgCa8", h.hash(testSalt, "Caf\u00e9 au lait"))
+	keyed, err := NewHasher(bytes.Repeat([]byte("k"), minKeyLength))
+	require.NoError(t, err)
+	assert.Equal(t, "YXInMqDvgLuxdrRZ6zPnIXqZwWpMg7vjBuWzUKK1z5g", keyed.hash(testSalt, "Caf\u00e9 au lait"))
+
+	// answers differing by their encoding or their white space only hash alike
+	for _, answer := range []string{"Cafe\u0301 au lait", "  Caf\u00e9   au\tlait\n"} {
+		assert.Equal(t, h.hash(testSalt, "Caf\u00e9 au lait"), h.hash(testSalt, answer), answer)
+	}
+	assert.NotEqual(t, h.hash(testSalt, "Caf\u00e9 au lait"), h.hash(testSalt, "caf\u00e9 au lait"))
+}
+
+func TestHashAnswers(t *testing.T) {
+	h, err := NewHasher(bytes.Repeat([]byte("k"), minKeyLength))
+	require.NoError(t, err)
+
+	salt, hashes, err := h.HashAnswers([]string{"answer1", "answer2", "answer1"})
+	require.NoError(t, err)
+	decoded, err := base64.RawURLEncoding.DecodeString(salt)
+	require.NoError(t, err)
+	assert.Len(t, decoded, saltLength)
+	require.Len(t, hashes, 3)
+	assert.Equal(t, h.hash(decoded, "answer1"), hashes[0])
+	assert.Equal(t, hashes[0], hashes[2])
+	assert.NotEqual(t, hashes[0], hashes[1])
+
+	// every record draws its own salt, so the same answers hash differently
+	other, otherHashes, err := h.HashAnswers([]string{"answer1", "answer2", "answer1"})
+	require.NoError(t, err)
+	assert.NotEqual(t, salt, other)
+	assert.NotEqual(t, hashes[0], otherHashes[0])
+}
+
+func TestNormalize(t *testing.T) {
+	for answer, expected := range map[string]string{
+		"":                       "",
+		"  ":                     "",
+		"answer":                 "answer",
+		" two\t \nwords ":        "two words",
+		"Cafe\u0301":             "Caf\u00e9",
+		"A\u030a":                "\u00c5",
+		"keeps Case and \u00fc ": "keeps Case and \u00fc",
+	} {
+		assert.Equal(t, expected, Normalize(answer), answer)
+	}
+}
+
+func TestHashSecret(t *testing.T) {
+	h, err := NewHasher(bytes.Repeat([]byte("k"), minKeyLength))
+	require.NoError(t, err)
+	salt := base64.RawURLEncoding.EncodeToString(testSalt)
+
+	hash, err := h.HashSecret(salt, "eyJhbGciOiJFUzI1NiJ9.e30.signature")
+	require.NoError(t, err)
+	assert.Equal(t, h.sum(testSalt, "eyJhbGciOiJFUzI1NiJ9.e30.signature"), hash)
+	// secrets are hashed as is, unlike answers
+	spaced, err := h.HashSecret(salt, " eyJhbGciOiJFUzI1NiJ9.e30.signature")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, spaced)
+
+	_, err = h.HashSecret("not base64!", "signature")
+	assert.Error(t, err)
+}
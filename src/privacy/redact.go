@@ -0,0 +1,38 @@
+package privacy
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// fingerprintLength is the number of base64url characters of the digest kept in a fingerprint
+const fingerprintLength = 12
+
+// Fingerprint returns a short digest of a high entropy secret such as a jwt or a signature, which tells log entries
+// about the same secret apart without revealing it. Low entropy values such as answers must go through Redact instead,
+// their digest is easily reversed.
+//
+// Parameters:
+//   - secret string: The secret to log
+//
+// Returns:
+//   - string: The fingerprint of the secret, empty for an empty secret
+func Fingerprint(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + base64.RawURLEncoding.EncodeToString(sum[:])[:fingerprintLength]
+}
+
+// Redact returns a placeholder telling how many values were left out of a log entry.
+//
+// Parameters:
+//   - values []string: The sensitive values
+//
+// Returns:
+//   - string: The placeholder
+func Redact(values []string) string {
+	return fmt.Sprintf("[%d redacted]", len(values))
+}
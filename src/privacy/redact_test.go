@@ -0,0 +1,19 @@
+package privacy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	// the first characters of the base64url encoded SHA-256 of the secret, computed independently
+	assert.Equal(t, "sha256:PZR9pV6ylvwL", Fingerprint("eyJhbGciOiJFUzI1NiJ9.e30.signature"))
+	assert.NotEqual(t, Fingerprint("eyJhbGciOiJFUzI1NiJ9.e30.signature"), Fingerprint("eyJhbGciOiJFUzI1NiJ9.e30.signaturf"))
+	assert.Empty(t, Fingerprint(""))
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "[2 redacted]", Redact([]string{"answer1", "answer2"}))
+	assert.Equal(t, "[0 redacted]", Redact(nil))
+}
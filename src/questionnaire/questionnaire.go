@@ -0,0 +1,195 @@
+package questionnaire
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"github.com/danbordeanu/go-logger"
+	"gopkg.in/yaml.v2"
+	"jwt-sign/configuration"
+)
+
+// AnswerType is the type an answer must parse as.
+type AnswerType string
+
+const (
+	// TypeText any text
+	TypeText AnswerType = "text"
+	// TypeInteger a base 10 integer
+	TypeInteger AnswerType = "integer"
+	// TypeNumber a decimal number
+	TypeNumber AnswerType = "number"
+	// TypeBoolean true or false
+	TypeBoolean AnswerType = "boolean"
+	// TypeDate a date formatted as 2006-01-02
+	TypeDate AnswerType = "date"
+	// TypeChoice one of the allowed values
+	TypeChoice AnswerType = "choice"
+)
+
+// Question is one question of a questionnaire and the constraints on its answer.
+type Question struct {
+	Id       string     `yaml:"id"`
+	Text     string     `yaml:"text"`
+	Type     AnswerType `yaml:"type"`
+	Required bool       `yaml:"required"`
+	Multiple bool       `yaml:"multiple"`
+	Allowed  []string   `yaml:"allowed"`
+	Pattern  string     `yaml:"pattern"`
+	pattern  *regexp.Regexp
+}
+
+// Questionnaire is a set of questions answered together.
+type Questionnaire struct {
+	Id        string     `yaml:"id"`
+	Title     string     `yaml:"title"`
+	Questions []Question `yaml:"questions"`
+}
+
+// Registry holds the known questionnaires.
+type Registry struct {
+	questionnaires map[string]*Questionnaire
+	fallback       string
+}
+
+var registry *Registry
+
+// Init loads the package registry from the questionnaires file of the application configuration. The registry is
+// empty, and answers are not validated against any questionnaire, when no file is configured.
+func Init(conf *configuration.Configuration) error {
+	log := logger.SugaredLogger().With("package", "questionnaire", "action", "Init")
+	if conf.QuestionnairesFile == "" {
+		log.Warnf("no questionnaires file configured, answers will not be validated!")
+		registry = NewRegistry(nil, "")
+		return nil
+	}
+	r, err := Load(conf.QuestionnairesFile, conf.QuestionnaireDefault)
+	if err != nil {
+		return err
+	}
+	log.Infof("loaded %d questionnaires from %s", len(r.questionnaires), conf.QuestionnairesFile)
+	registry = r
+	return nil
+}
+
+// Default returns the package registry initialized by Init.
+func Default() *Registry {
+	return registry
+}
+
+// Load reads and checks the questionnaires of a yaml file holding a questionnaires list.
+//
+// Parameters:
+//   - path string: Path of the yaml file
+//   - fallback string: Id of the questionnaire used when the jwt references none, may be empty
+//
+// Returns:
+//   - *Registry: The questionnaires
+//   - error: An error if the file cannot be read or a questionnaire is invalid
+func Load(path, fallback string) (*Registry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading questionnaires file %s: %s", path, err.Error())
+	}
+	var file struct {
+		Questionnaires []*Questionnaire `yaml:"questionnaires"`
+	}
+	if err = yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing questionnaires file %s: %s", path, err.Error())
+	}
+	for _, q := range file.Questionnaires {
+		if err = q.compile(); err != nil {
+			return nil, err
+		}
+	}
+	r := NewRegistry(file.Questionnaires, fallback)
+	if len(r.questionnaires) != len(file.Questionnaires) {
+		return nil, fmt.Errorf("questionnaire ids are not unique in %s", path)
+	}
+	if fallback != "" && r.questionnaires[fallback] == nil {
+		return nil, fmt.Errorf("default questionnaire %q is not defined in %s", fallback, path)
+	}
+	return r, nil
+}
+
+// NewRegistry creates a Registry from compiled questionnaires.
+//
+// Parameters:
+//   - questionnaires []*Questionnaire: The questionnaires
+//   - fallback string: Id of the questionnaire used when the jwt references none, may be empty
+//
+// Returns:
+//   - *Registry: The registry
+func NewRegistry(questionnaires []*Questionnaire, fallback string) *Registry {
+	r := &Registry{questionnaires: map[string]*Questionnaire{}, fallback: fallback}
+	for _, q := range questionnaires {
+		r.questionnaires[q.Id] = q
+	}
+	return r
+}
+
+// Enabled reports whether answers are validated, that is whether any questionnaire is defined.
+func (r *Registry) Enabled() bool {
+	return len(r.questionnaires) > 0
+}
+
+// Lookup returns the questionnaire with the id, or the default questionnaire when id is empty.
+//
+// Parameters:
+//   - id string: Id of the questionnaire referenced by the jwt, may be empty
+//
+// Returns:
+//   - *Questionnaire: The questionnaire
+//   - bool: false when there is no such questionnaire
+func (r *Registry) Lookup(id string) (*Questionnaire, bool) {
+	if id == "" {
+		id = r.fallback
+	}
+	q, ok := r.questionnaires[id]
+	return q, ok
+}
+
+// compile checks the definition of the questionnaire and compiles the patterns of its questions.
+func (q *Questionnaire) compile() error {
+	if q.Id == "" {
+		return fmt.Errorf("questionnaire without id")
+	}
+	if len(q.Questions) == 0 {
+		return fmt.Errorf("questionnaire %s has no questions", q.Id)
+	}
+	seen := map[string]bool{}
+	for i := range q.Questions {
+		question := &q.Questions[i]
+		if question.Id == "" {
+			return fmt.Errorf("question %d of questionnaire %s has no id", i, q.Id)
+		}
+		if seen[question.Id] {
+			return fmt.Errorf("question %s of questionnaire %s is defined twice", question.Id, q.Id)
+		}
+		seen[question.Id] = true
+		switch question.Type {
+		case "":
+			question.Type = TypeText
+		case TypeText, TypeInteger, TypeNumber, TypeBoolean, TypeDate:
+		case TypeChoice:
+			if len(question.Allowed) == 0 {
+				return fmt.Errorf("choice question %s of questionnaire %s has no allowed values", question.Id, q.Id)
+			}
+		default:
+			return fmt.Errorf("question %s of questionnaire %s has unknown type %q", question.Id, q.Id, question.Type)
+		}
+		if question.Multiple && question.Type != TypeChoice {
+			return fmt.Errorf("question %s of questionnaire %s allows multiple answers but is not a choice", question.Id, q.Id)
+		}
+		if question.Pattern != "" {
+			// the pattern must match the whole answer
+			pattern, err := regexp.Compile("^(?:" + question.Pattern + ")$")
+			if err != nil {
+				return fmt.Errorf("question %s of questionnaire %s has an invalid pattern: %s", question.Id, q.Id, err.Error())
+			}
+			question.pattern = pattern
+		}
+	}
+	return nil
+}
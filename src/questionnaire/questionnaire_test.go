@@ -0,0 +1,108 @@
+package questionnaire
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testQuestionnaires defines a questionnaire with a question of every type.
+const testQuestionnaires = `
+questionnaires:
+  - id: onboarding
+    title: Onboarding
+    questions:
+      - id: name
+        text: What is your name?
+        required: true
+      - id: age
+        type: integer
+      - id: height
+        type: number
+      - id: consent
+        type: boolean
+        required: true
+      - id: birthday
+        type: date
+      - id: country
+        type: choice
+        allowed: [DE, FR, RO]
+      - id: languages
+        type: choice
+        multiple: true
+        allowed: [de, en, fr, ro]
+      - id: zip
+        pattern: '[0-9]{5}'
+  - id: feedback
+    questions:
+      - id: rating
+        type: integer
+        required: true
+`
+
+// writeQuestionnaires writes the yaml content to a questionnaires file and returns its path.
+func writeQuestionnaires(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "questionnaires.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func loadTestQuestionnaire(t *testing.T) *Questionnaire {
+	t.Helper()
+	r, err := Load(writeQuestionnaires(t, testQuestionnaires), "onboarding")
+	require.NoError(t, err)
+	q, ok := r.Lookup("onboarding")
+	require.True(t, ok)
+	return q
+}
+
+func TestLoad(t *testing.T) {
+	r, err := Load(writeQuestionnaires(t, testQuestionnaires), "onboarding")
+	require.NoError(t, err)
+	assert.True(t, r.Enabled())
+
+	q, ok := r.Lookup("")
+	require.True(t, ok)
+	assert.Equal(t, "onboarding", q.Id)
+	assert.Equal(t, TypeText, q.Questions[0].Type)
+	q, ok = r.Lookup("feedback")
+	require.True(t, ok)
+	assert.Equal(t, "feedback", q.Id)
+	_, ok = r.Lookup("unknown")
+	assert.False(t, ok)
+
+	// without a default questionnaire the jwt must reference one
+	r, err = Load(writeQuestionnaires(t, testQuestionnaires), "")
+	require.NoError(t, err)
+	_, ok = r.Lookup("")
+	assert.False(t, ok)
+
+	assert.False(t, NewRegistry(nil, "").Enabled())
+}
+
+func TestLoadRejectsInvalidQuestionnaires(t *testing.T) {
+	for name, content := range map[string]string{
+		"not yaml":              `questionnaires: [`,
+		"unknown field":         "questionnaires:\n  - id: q\n    questions:\n      - id: a\n        kind: text\n",
+		"no id":                 "questionnaires:\n  - questions:\n      - id: a\n",
+		"no questions":          "questionnaires:\n  - id: q\n",
+		"question without id":   "questionnaires:\n  - id: q\n    questions:\n      - text: a\n",
+		"question twice":        "questionnaires:\n  - id: q\n    questions:\n      - id: a\n      - id: a\n",
+		"unknown type":          "questionnaires:\n  - id: q\n    questions:\n      - id: a\n        type: color\n",
+		"choice without values": "questionnaires:\n  - id: q\n    questions:\n      - id: a\n        type: choice\n",
+		"multiple text":         "questionnaires:\n  - id: q\n    questions:\n      - id: a\n        multiple: true\n",
+		"invalid pattern":       "questionnaires:\n  - id: q\n    questions:\n      - id: a\n        pattern: '[0-9'\n",
+		"ids not unique":        "questionnaires:\n  - id: q\n    questions:\n      - id: a\n  - id: q\n    questions:\n      - id: b\n",
+	} {
+		_, err := Load(writeQuestionnaires(t, content), "")
+		assert.Error(t, err, name)
+	}
+
+	path := writeQuestionnaires(t, testQuestionnaires)
+	_, err := Load(path, "unknown")
+	assert.EqualError(t, err, `default questionnaire "unknown" is not defined in `+path)
+}
@@ -0,0 +1,246 @@
+package questionnaire
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"jwt-sign/model"
+)
+
+// Field error codes
+const (
+	// CodeCountMismatch the number of questions and answers differ
+	CodeCountMismatch = "count_mismatch"
+	// CodeUnknownQuestionnaire the jwt references no known questionnaire
+	CodeUnknownQuestionnaire = "unknown_questionnaire"
+	// CodeUnknownQuestion the question is not part of the questionnaire
+	CodeUnknownQuestion = "unknown_question"
+	// CodeDuplicateQuestion the question is answered more than once
+	CodeDuplicateQuestion = "duplicate_question"
+	// CodeRequired the required question is not answered
+	CodeRequired = "required"
+	// CodeInvalidType the answer does not parse as the type of the question
+	CodeInvalidType = "invalid_type"
+	// CodeNotAllowed the answer is not one of the allowed values
+	CodeNotAllowed = "not_allowed"
+	// CodePatternMismatch the answer does not match the pattern of the question
+	CodePatternMismatch = "pattern_mismatch"
+)
+
+// ValidationError lists the fields of a request rejected by a questionnaire.
+type ValidationError struct {
+	Questionnaire string
+	Fields        []model.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	if e.Questionnaire == "" {
+		return fmt.Sprintf("invalid answers: %s", strings.Join(messages, "; "))
+	}
+	return fmt.Sprintf("answers do not match questionnaire %s: %s", e.Questionnaire, strings.Join(messages, "; "))
+}
+
+// entry is one answered question of a request, along with the names of its fields in the request.
+type entry struct {
+	questionField string
+	answerField   string
+	question      string
+	byText        bool
+	value         interface{}
+}
+
+// Validate checks answers posted as parallel lists against the questionnaire. Questions are matched by id or by text.
+//
+// Parameters:
+//   - questions []string: Ids or texts of the answered questions
+//   - answers []string: Answers corresponding to the questions
+//
+// Returns:
+//   - error: A *ValidationError listing every rejected field, nil if the answers are valid
+func (q *Questionnaire) Validate(questions, answers []string) error {
+	if err := CheckCount(questions, answers); err != nil {
+		err.Questionnaire = q.Id
+		return err
+	}
+	entries := make([]entry, len(questions))
+	for i := range questions {
+		entries[i] = entry{questionField: fmt.Sprintf("questions[%d]", i), answerField: fmt.Sprintf("answers[%d]", i),
+			question: questions[i], byText: true, value: answers[i]}
+	}
+	return q.validate(entries)
+}
+
+// CheckCount checks that answers posted as parallel lists pair up, whether or not a questionnaire applies to them.
+//
+// Parameters:
+//   - questions []string: Ids or texts of the answered questions
+//   - answers []string: Answers corresponding to the questions
+//
+// Returns:
+//   - *ValidationError: The count_mismatch field error, nil if there are as many answers as questions
+func CheckCount(questions, answers []string) *ValidationError {
+	if len(questions) == len(answers) {
+		return nil
+	}
+	return &ValidationError{Fields: []model.FieldError{{Field: "answers", Code: CodeCountMismatch,
+		Message: fmt.Sprintf("got %d questions but %d answers", len(questions), len(answers))}}}
+}
+
+// ValidateStructured checks typed answers against the questionnaire. Questions are matched by id.
+//
+// Parameters:
+//   - answers []model.AnswerItem: The typed answers
+//
+// Returns:
+//   - error: A *ValidationError listing every rejected field, nil if the answers are valid
+func (q *Questionnaire) ValidateStructured(answers []model.AnswerItem) error {
+	entries := make([]entry, len(answers))
+	for i, item := range answers {
+		entries[i] = entry{questionField: fmt.Sprintf("answers[%d].questionId", i),
+			answerField: fmt.Sprintf("answers[%d].answer", i), question: item.QuestionId, value: item.Answer.Value}
+	}
+	return q.validate(entries)
+}
+
+// validate checks the entries against the questionnaire.
+func (q *Questionnaire) validate(entries []entry) error {
+	var fields []model.FieldError
+	answered := map[string]bool{}
+	for _, e := range entries {
+		question := q.question(e.question, e.byText)
+		if question == nil {
+			fields = append(fields, model.FieldError{Field: e.questionField, Code: CodeUnknownQuestion,
+				Message: fmt.Sprintf("question %q is not part of questionnaire %s", e.question, q.Id)})
+			continue
+		}
+		if answered[question.Id] {
+			fields = append(fields, model.FieldError{Field: e.questionField, QuestionId: question.Id,
+				Code: CodeDuplicateQuestion, Message: "question is answered more than once"})
+			continue
+		}
+		if empty(e.value) {
+			// an empty answer is a missing answer, reported below when required
+			continue
+		}
+		answered[question.Id] = true
+		if code, message := question.check(e.value); code != "" {
+			fields = append(fields, model.FieldError{Field: e.answerField, QuestionId: question.Id,
+				Code: code, Message: message})
+		}
+	}
+	for _, question := range q.Questions {
+		if question.Required && !answered[question.Id] {
+			fields = append(fields, model.FieldError{Field: "answers", QuestionId: question.Id, Code: CodeRequired,
+				Message: fmt.Sprintf("question %s requires an answer", question.Id)})
+		}
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Questionnaire: q.Id, Fields: fields}
+	}
+	return nil
+}
+
+// question returns the question of the questionnaire with the id, or else the text when byText is set, nil if there
+// is none.
+func (q *Questionnaire) question(asked string, byText bool) *Question {
+	for i := range q.Questions {
+		if q.Questions[i].Id == asked {
+			return &q.Questions[i]
+		}
+	}
+	if !byText {
+		return nil
+	}
+	for i := range q.Questions {
+		if q.Questions[i].Text != "" && q.Questions[i].Text == asked {
+			return &q.Questions[i]
+		}
+	}
+	return nil
+}
+
+// check returns the code and message of the first constraint the answer violates, an empty code if it violates none.
+// Typed answers must have the type of the question, string answers must parse as it.
+func (question *Question) check(value interface{}) (string, string) {
+	var values []string
+	switch v := value.(type) {
+	case []string:
+		if !question.Multiple {
+			return CodeInvalidType, "question accepts a single answer"
+		}
+		values = v
+	case string:
+		v = strings.TrimSpace(v)
+		if !question.parses(v) {
+			return CodeInvalidType, fmt.Sprintf("answer is not a valid %s", question.Type)
+		}
+		values = []string{v}
+	case float64:
+		if question.Type != TypeNumber && (question.Type != TypeInteger || v != math.Trunc(v)) {
+			return CodeInvalidType, fmt.Sprintf("answer is not a valid %s", question.Type)
+		}
+		values = []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case bool:
+		if question.Type != TypeBoolean {
+			return CodeInvalidType, fmt.Sprintf("answer is not a valid %s", question.Type)
+		}
+		values = []string{strconv.FormatBool(v)}
+	default:
+		return CodeInvalidType, fmt.Sprintf("answer is not a valid %s", question.Type)
+	}
+	for _, v := range values {
+		if len(question.Allowed) > 0 && !contains(question.Allowed, v) {
+			return CodeNotAllowed, fmt.Sprintf("answer must be one of %s", strings.Join(question.Allowed, ", "))
+		}
+		if question.pattern != nil && !question.pattern.MatchString(v) {
+			return CodePatternMismatch, fmt.Sprintf("answer does not match %s", question.Pattern)
+		}
+	}
+	return "", ""
+}
+
+// parses reports whether the string answer parses as the type of the question.
+func (question *Question) parses(answer string) bool {
+	var err error
+	switch question.Type {
+	case TypeInteger:
+		_, err = strconv.ParseInt(answer, 10, 64)
+	case TypeNumber:
+		_, err = strconv.ParseFloat(answer, 64)
+	case TypeBoolean:
+		_, err = strconv.ParseBool(answer)
+	case TypeDate:
+		_, err = time.Parse("2006-01-02", answer)
+	}
+	return err == nil
+}
+
+// empty reports whether an answer is missing: nil, blank or no choice at all.
+func empty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
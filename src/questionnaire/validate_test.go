@@ -0,0 +1,114 @@
+package questionnaire
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/model"
+)
+
+// fieldCodes returns the field and code of every rejected field of a validation error.
+func fieldCodes(t *testing.T, err error) [][2]string {
+	t.Helper()
+	var validationError *ValidationError
+	require.True(t, errors.As(err, &validationError), "%v", err)
+	codes := make([][2]string, len(validationError.Fields))
+	for i, f := range validationError.Fields {
+		codes[i] = [2]string{f.Field, f.Code}
+	}
+	return codes
+}
+
+func TestValidate(t *testing.T) {
+	q := loadTestQuestionnaire(t)
+
+	// questions are matched by id or text, answers are trimmed before they are parsed
+	assert.NoError(t, q.Validate(
+		[]string{"What is your name?", "age", "height", "consent", "birthday", "country", "zip"},
+		[]string{"JonnyBoy", " 42 ", "1.85", "true", "1990-12-31", "RO", "12345"},
+	))
+	// optional questions may be left out or blank
+	assert.NoError(t, q.Validate([]string{"name", "consent", "age"}, []string{"JonnyBoy", "false", " "}))
+
+	for name, test := range map[string]struct {
+		questions, answers []string
+		expected           [][2]string
+	}{
+		"count mismatch": {[]string{"name", "consent"}, []string{"JonnyBoy"},
+			[][2]string{{"answers", CodeCountMismatch}}},
+		"unknown question": {[]string{"name", "consent", "color"}, []string{"JonnyBoy", "true", "blue"},
+			[][2]string{{"questions[2]", CodeUnknownQuestion}}},
+		"duplicate question": {[]string{"name", "consent", "What is your name?"}, []string{"JonnyBoy", "true", "Jonny"},
+			[][2]string{{"questions[2]", CodeDuplicateQuestion}}},
+		"required": {[]string{"name", "consent"}, []string{"JonnyBoy", ""},
+			[][2]string{{"answers", CodeRequired}}},
+		"invalid types": {[]string{"name", "consent", "age", "height", "birthday"}, []string{"JonnyBoy", "yes", "4.2", "tall", "31.12.1990"},
+			[][2]string{{"answers[1]", CodeInvalidType}, {"answers[2]", CodeInvalidType}, {"answers[3]", CodeInvalidType}, {"answers[4]", CodeInvalidType}}},
+		"not allowed": {[]string{"name", "consent", "country"}, []string{"JonnyBoy", "true", "ro"},
+			[][2]string{{"answers[2]", CodeNotAllowed}}},
+		"pattern mismatch": {[]string{"name", "consent", "zip"}, []string{"JonnyBoy", "true", "123456"},
+			[][2]string{{"answers[2]", CodePatternMismatch}}},
+		"every field": {[]string{"color", "age"}, []string{"blue", "old"},
+			[][2]string{{"questions[0]", CodeUnknownQuestion}, {"answers[1]", CodeInvalidType}, {"answers", CodeRequired}, {"answers", CodeRequired}}},
+	} {
+		assert.Equal(t, test.expected, fieldCodes(t, q.Validate(test.questions, test.answers)), name)
+	}
+}
+
+func TestValidateStructured(t *testing.T) {
+	q := loadTestQuestionnaire(t)
+	answers := func(items ...interface{}) []model.AnswerItem {
+		list := make([]model.AnswerItem, 0, len(items)/2)
+		for i := 0; i < len(items); i += 2 {
+			list = append(list, model.AnswerItem{QuestionId: items[i].(string), Answer: model.AnswerValue{Value: items[i+1]}})
+		}
+		return list
+	}
+
+	assert.NoError(t, q.ValidateStructured(answers(
+		"name", "JonnyBoy", "age", 42.0, "height", 1.85, "consent", false, "birthday", "1990-12-31",
+		"country", "RO", "languages", []string{"de", "ro"}, "zip", "12345",
+	)))
+	// typed answers may also be posted as strings
+	assert.NoError(t, q.ValidateStructured(answers("name", "JonnyBoy", "age", "42", "consent", "true")))
+	// no choice at all is a missing answer
+	assert.NoError(t, q.ValidateStructured(answers("name", "JonnyBoy", "consent", true, "languages", []string{})))
+
+	for name, test := range map[string]struct {
+		answers  []model.AnswerItem
+		expected [][2]string
+	}{
+		// structured answers are matched by id only
+		"question text": {answers("What is your name?", "JonnyBoy", "consent", true),
+			[][2]string{{"answers[0].questionId", CodeUnknownQuestion}, {"answers", CodeRequired}}},
+		"fractional integer": {answers("name", "JonnyBoy", "consent", true, "age", 4.2),
+			[][2]string{{"answers[2].answer", CodeInvalidType}}},
+		"number as boolean": {answers("name", "JonnyBoy", "consent", 1.0),
+			[][2]string{{"answers[1].answer", CodeInvalidType}}},
+		"boolean as text": {answers("name", true, "consent", true),
+			[][2]string{{"answers[0].answer", CodeInvalidType}}},
+		"multiple single choice": {answers("name", "JonnyBoy", "consent", true, "country", []string{"RO"}),
+			[][2]string{{"answers[2].answer", CodeInvalidType}}},
+		"one choice not allowed": {answers("name", "JonnyBoy", "consent", true, "languages", []string{"de", "it"}),
+			[][2]string{{"answers[2].answer", CodeNotAllowed}}},
+		"missing": {answers("name", nil),
+			[][2]string{{"answers", CodeRequired}, {"answers", CodeRequired}}},
+	} {
+		assert.Equal(t, test.expected, fieldCodes(t, q.ValidateStructured(test.answers)), name)
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	q := loadTestQuestionnaire(t)
+	err := q.Validate([]string{"name", "consent", "country"}, []string{"JonnyBoy", "true", "IT"})
+	assert.EqualError(t, err, "answers do not match questionnaire onboarding: answers[2]: answer must be one of DE, FR, RO")
+}
+
+func TestCheckCount(t *testing.T) {
+	assert.Nil(t, CheckCount([]string{"name"}, []string{"JonnyBoy"}))
+	err := CheckCount([]string{"name", "country"}, []string{"JonnyBoy"})
+	assert.Equal(t, [][2]string{{"answers", CodeCountMismatch}}, fieldCodes(t, err))
+	assert.EqualError(t, err, "invalid answers: answers: got 2 questions but 1 answers")
+}
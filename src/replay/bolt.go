@@ -0,0 +1,114 @@
+package replay
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// usedBucket bucket holding nonce -> expiry (unix seconds, big endian)
+var usedBucket = []byte("used")
+
+// BoltCache is an on-disk Cache backed by an embedded bbolt database.
+type BoltCache struct {
+	db *bolt.DB
+}
+
+// NewBoltCache opens, or creates, the database file.
+//
+// Parameters:
+//   - path string: Path of the database file
+//
+// Returns:
+//   - *BoltCache: The cache
+//   - error: An error if the database cannot be opened
+func NewBoltCache(path string) (*BoltCache, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening nonce cache %s: %s", path, err.Error())
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(usedBucket)
+		return err
+	})
+	if err != nil {
+		//goland:noinspection GoUnhandledErrorResult
+		db.Close()
+		return nil, fmt.Errorf("error initializing nonce cache %s: %s", path, err.Error())
+	}
+	return &BoltCache{db: db}, nil
+}
+
+// Claim marks the key as used until expiresAt and reports whether it was unused. bbolt runs a single read-write
+// transaction at a time, which makes the check and the write atomic.
+func (b *BoltCache) Claim(key string, expiresAt time.Time) (bool, error) {
+	claimed := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(usedBucket)
+		if v := bucket.Get([]byte(key)); v != nil && !expired(decodeExpiry(v), time.Now()) {
+			return nil
+		}
+		claimed = true
+		return bucket.Put([]byte(key), encodeExpiry(expiresAt))
+	})
+	return claimed, err
+}
+
+// Release forgets the key.
+func (b *BoltCache) Release(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(usedBucket).Delete([]byte(key))
+	})
+}
+
+// Prune removes the entries whose token expired before now.
+func (b *BoltCache) Prune(now time.Time) (int, error) {
+	pruned := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(usedBucket)
+		var stale [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			if expired(decodeExpiry(v), now) {
+				// keys are only valid for the life of the transaction and must not be deleted while iterating
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err = bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(stale)
+		return nil
+	})
+	return pruned, err
+}
+
+// Close closes the database.
+func (b *BoltCache) Close() error {
+	return b.db.Close()
+}
+
+// encodeExpiry encodes expiresAt as unix seconds, 0 for the zero time.
+func encodeExpiry(expiresAt time.Time) []byte {
+	var value [8]byte
+	if !expiresAt.IsZero() {
+		binary.BigEndian.PutUint64(value[:], uint64(expiresAt.Unix()))
+	}
+	return value[:]
+}
+
+// decodeExpiry decodes a value written by encodeExpiry.
+func decodeExpiry(v []byte) time.Time {
+	seconds := binary.BigEndian.Uint64(v)
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0)
+}
@@ -0,0 +1,60 @@
+package replay
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryCache is an in-memory Cache, its content is lost on restart.
+type MemoryCache struct {
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+// NewMemoryCache creates an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{used: map[string]time.Time{}}
+}
+
+// Claim marks the key as used until expiresAt and reports whether it was unused.
+func (m *MemoryCache) Claim(key string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if previous, ok := m.used[key]; ok && !expired(previous, time.Now()) {
+		return false, nil
+	}
+	m.used[key] = expiresAt
+	return true, nil
+}
+
+// Release forgets the key.
+func (m *MemoryCache) Release(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.used, key)
+	return nil
+}
+
+// Prune removes the entries whose token expired before now.
+func (m *MemoryCache) Prune(now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pruned := 0
+	for key, expiresAt := range m.used {
+		if expired(expiresAt, now) {
+			delete(m.used, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// Close is a no-op.
+func (m *MemoryCache) Close() error {
+	return nil
+}
+
+// expired reports whether an entry kept until expiresAt can be forgotten at now.
+func expired(expiresAt, now time.Time) bool {
+	return !expiresAt.IsZero() && expiresAt.Before(now)
+}
@@ -0,0 +1,103 @@
+package replay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"jwt-sign/configuration"
+)
+
+// Cache remembers the tokens already submitted until they expire. Claims for the same key are serialized, only the
+// first one succeeds.
+type Cache interface {
+	// Claim marks the key as used until expiresAt and reports whether it was unused. A zero expiresAt keeps the
+	// entry forever.
+	Claim(key string, expiresAt time.Time) (bool, error)
+	// Release forgets the key so the token can be submitted again.
+	Release(key string) error
+	// Prune removes the entries whose token expired before now and returns how many were removed.
+	Prune(now time.Time) (int, error)
+	// Close releases the resources held by the cache.
+	Close() error
+}
+
+var cache Cache
+
+// Init builds the package nonce cache from the application configuration: an on-disk cache when a file is configured,
+// an in-memory cache otherwise. Nothing is built when replay protection is disabled. Expired entries are pruned
+// periodically until ctx is cancelled.
+func Init(ctx context.Context, conf *configuration.Configuration) error {
+	log := logger.SugaredLogger().With("package", "replay", "action", "Init")
+	if !conf.ReplayProtectionEnabled {
+		log.Warnf("replay protection is disabled, tokens can be submitted more than once!")
+		cache = nil
+		return nil
+	}
+	if conf.ReplayStoreFile != "" {
+		c, err := NewBoltCache(conf.ReplayStoreFile)
+		if err != nil {
+			return err
+		}
+		log.Infof("nonce cache opened at %s", conf.ReplayStoreFile)
+		cache = c
+	} else {
+		log.Warnf("no nonce cache file configured, used tokens will be forgotten after a restart!")
+		cache = NewMemoryCache()
+	}
+	if conf.ReplayPruneSec > 0 {
+		concurrency.GlobalWaitGroup.Add(1)
+		go Watch(ctx, cache, time.Duration(conf.ReplayPruneSec)*time.Second)
+	}
+	return nil
+}
+
+// Default returns the package nonce cache initialized by Init, nil when replay protection is disabled.
+func Default() Cache {
+	return cache
+}
+
+// Key returns the nonce of a token: its jti within the namespace of its issuer when it has one, as issuers pick their
+// jtis independently, the hash of the raw token otherwise.
+//
+// Parameters:
+//   - raw string: The compact token
+//   - issuer string: The iss claim of the token, may be empty
+//   - jti string: The jti claim of the token, may be empty
+//
+// Returns:
+//   - string: The nonce to claim
+func Key(raw, issuer, jti string) string {
+	if jti != "" {
+		return "jti:" + issuer + "\x00" + jti
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return "sha256:" + base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Watch prunes the expired entries of the cache every interval until ctx is cancelled.
+func Watch(ctx context.Context, c Cache, interval time.Duration) {
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().With("package", "replay", "action", "Watch")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debugf("nonce cache pruner terminated")
+			return
+		case <-ticker.C:
+			n, err := c.Prune(time.Now())
+			if err != nil {
+				log.Errorf("error pruning nonce cache: %s", err.Error())
+				continue
+			}
+			if n > 0 {
+				log.Debugf("pruned %d expired nonces", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package replay
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// caches returns every Cache implementation, backed by a fresh store.
+func caches(t *testing.T) map[string]Cache {
+	bolt, err := NewBoltCache(filepath.Join(t.TempDir(), "replay.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bolt.Close() })
+	return map[string]Cache{"memory": NewMemoryCache(), "bolt": bolt}
+}
+
+func TestCacheClaimOnce(t *testing.T) {
+	for name, cache := range caches(t) {
+		t.Run(name, func(t *testing.T) {
+			expiresAt := time.Now().Add(time.Minute)
+			claimed, err := cache.Claim("nonce", expiresAt)
+			require.NoError(t, err)
+			assert.True(t, claimed)
+			claimed, err = cache.Claim("nonce", expiresAt)
+			require.NoError(t, err)
+			assert.False(t, claimed)
+
+			require.NoError(t, cache.Release("nonce"))
+			claimed, err = cache.Claim("nonce", expiresAt)
+			require.NoError(t, err)
+			assert.True(t, claimed)
+		})
+	}
+}
+
+func TestCacheConcurrentClaims(t *testing.T) {
+	for name, cache := range caches(t) {
+		t.Run(name, func(t *testing.T) {
+			const claims = 16
+			results := make(chan bool, claims)
+			var wg sync.WaitGroup
+			for i := 0; i < claims; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					claimed, err := cache.Claim("nonce", time.Now().Add(time.Minute))
+					assert.NoError(t, err)
+					results <- claimed
+				}()
+			}
+			wg.Wait()
+			close(results)
+			won := 0
+			for claimed := range results {
+				if claimed {
+					won++
+				}
+			}
+			assert.Equal(t, 1, won)
+		})
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	for name, cache := range caches(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			_, err := cache.Claim("expired", now.Add(-2*time.Second))
+			require.NoError(t, err)
+			_, err = cache.Claim("valid", now.Add(time.Minute))
+			require.NoError(t, err)
+			_, err = cache.Claim("forever", time.Time{})
+			require.NoError(t, err)
+
+			// an expired entry no longer blocks the key
+			claimed, err := cache.Claim("expired", now.Add(time.Minute))
+			require.NoError(t, err)
+			assert.True(t, claimed)
+			claimed, err = cache.Claim("forever", time.Time{})
+			require.NoError(t, err)
+			assert.False(t, claimed)
+
+			pruned, err := cache.Prune(now.Add(2 * time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, 2, pruned)
+			claimed, err = cache.Claim("forever", time.Time{})
+			require.NoError(t, err)
+			assert.False(t, claimed)
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "jti:https://idp.example\x00abc", Key("token", "https://idp.example", "abc"))
+	assert.Equal(t, Key("token", "https://idp.example", "abc"), Key("other", "https://idp.example", "abc"))
+	// issuers pick their jtis independently
+	assert.NotEqual(t, Key("token", "https://idp.example", "abc"), Key("token", "https://other.example", "abc"))
+	assert.NotEqual(t, Key("token", "", "abc"), Key("token", "https://idp.example", "abc"))
+	assert.Equal(t, Key("token", "", ""), Key("token", "https://idp.example", ""))
+	assert.NotEqual(t, Key("token", "", ""), Key("other", "", ""))
+}
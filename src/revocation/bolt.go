@@ -0,0 +1,96 @@
+package revocation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// revokedBucket bucket holding issuer NUL jti -> expiry (unix seconds, big endian)
+var revokedBucket = []byte("revoked")
+
+// BoltStore is an on-disk Store backed by an embedded bbolt database.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens, or creates, the database file.
+//
+// Parameters:
+//   - path string: Path of the database file
+//
+// Returns:
+//   - *BoltStore: The store
+//   - error: An error if the database cannot be opened
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening revocation store %s: %s", path, err.Error())
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(revokedBucket)
+		return err
+	})
+	if err != nil {
+		//goland:noinspection GoUnhandledErrorResult
+		db.Close()
+		return nil, fmt.Errorf("error initializing revocation store %s: %s", path, err.Error())
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Revoke denylists the jti of the issuer until expiresAt.
+func (s *BoltStore) Revoke(issuer, jti string, expiresAt time.Time) error {
+	var value [8]byte
+	if !expiresAt.IsZero() {
+		binary.BigEndian.PutUint64(value[:], uint64(expiresAt.Unix()))
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(revokedBucket).Put([]byte(key(issuer, jti)), value[:])
+	})
+}
+
+// IsRevoked reports whether the jti of the issuer is denylisted.
+func (s *BoltStore) IsRevoked(issuer, jti string) (bool, error) {
+	revoked := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		revoked = tx.Bucket(revokedBucket).Get([]byte(key(issuer, jti))) != nil
+		return nil
+	})
+	return revoked, err
+}
+
+// Prune removes the entries whose token expired before now.
+func (s *BoltStore) Prune(now time.Time) (int, error) {
+	pruned := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(revokedBucket)
+		var expired [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			expiresAt := binary.BigEndian.Uint64(v)
+			if expiresAt != 0 && int64(expiresAt) < now.Unix() {
+				// keys are only valid for the life of the transaction and must not be deleted while iterating
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err = bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(expired)
+		return nil
+	})
+	return pruned, err
+}
+
+// Close closes the database.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
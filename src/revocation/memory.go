@@ -0,0 +1,52 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-memory Store, its content is lost on restart.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{revoked: map[string]time.Time{}}
+}
+
+// Revoke denylists the jti of the issuer until expiresAt.
+func (s *MemoryStore) Revoke(issuer, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[key(issuer, jti)] = expiresAt
+	return nil
+}
+
+// IsRevoked reports whether the jti of the issuer is denylisted.
+func (s *MemoryStore) IsRevoked(issuer, jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.revoked[key(issuer, jti)]
+	return ok, nil
+}
+
+// Prune removes the entries whose token expired before now.
+func (s *MemoryStore) Prune(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pruned := 0
+	for k, expiresAt := range s.revoked {
+		if !expiresAt.IsZero() && expiresAt.Before(now) {
+			delete(s.revoked, k)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// Close is a no-op.
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"jwt-sign/configuration"
+)
+
+// Store keeps the jti of revoked tokens until the tokens expire. A jti is scoped to its issuer, as issuers pick their
+// jtis independently.
+type Store interface {
+	// Revoke denylists the jti of the issuer until expiresAt. A zero expiresAt keeps the entry forever.
+	Revoke(issuer, jti string, expiresAt time.Time) error
+	// IsRevoked reports whether the jti of the issuer is denylisted.
+	IsRevoked(issuer, jti string) (bool, error)
+	// Prune removes the entries whose token expired before now and returns how many were removed.
+	Prune(now time.Time) (int, error)
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+var store Store
+
+// key returns the entry of the jti of the issuer, the issuer may be empty.
+func key(issuer, jti string) string {
+	return issuer + "\x00" + jti
+}
+
+// Init builds the package revocation store from the application configuration: an on-disk store when a file is
+// configured, an in-memory store otherwise. Expired entries are pruned periodically until ctx is cancelled.
+func Init(ctx context.Context, conf *configuration.Configuration) error {
+	log := logger.SugaredLogger().With("package", "revocation", "action", "Init")
+	if conf.RevocationStoreFile != "" {
+		s, err := NewBoltStore(conf.RevocationStoreFile)
+		if err != nil {
+			return err
+		}
+		log.Infof("revocation store opened at %s", conf.RevocationStoreFile)
+		store = s
+	} else {
+		log.Warnf("no revocation store file configured, revoked tokens will be forgotten after a restart!")
+		store = NewMemoryStore()
+	}
+	if conf.RevocationPruneSec > 0 {
+		concurrency.GlobalWaitGroup.Add(1)
+		go Watch(ctx, store, time.Duration(conf.RevocationPruneSec)*time.Second)
+	}
+	return nil
+}
+
+// Default returns the package revocation store initialized by Init.
+func Default() Store {
+	return store
+}
+
+// Watch prunes the expired entries of the store every interval until ctx is cancelled.
+func Watch(ctx context.Context, s Store, interval time.Duration) {
+	defer concurrency.GlobalWaitGroup.Done()
+	log := logger.SugaredLogger().With("package", "revocation", "action", "Watch")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debugf("revocation pruner terminated")
+			return
+		case <-ticker.C:
+			n, err := s.Prune(time.Now())
+			if err != nil {
+				log.Errorf("error pruning revocation store: %s", err.Error())
+				continue
+			}
+			if n > 0 {
+				log.Debugf("pruned %d expired revocations", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package revocation
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stores returns every Store implementation, backed by a fresh store.
+func stores(t *testing.T) map[string]Store {
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "revocation.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bolt.Close() })
+	return map[string]Store{"memory": NewMemoryStore(), "bolt": bolt}
+}
+
+func TestStoreRevoke(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			revoked, err := store.IsRevoked("https://idp.example", "jti")
+			require.NoError(t, err)
+			assert.False(t, revoked)
+
+			require.NoError(t, store.Revoke("https://idp.example", "jti", time.Now().Add(time.Minute)))
+			revoked, err = store.IsRevoked("https://idp.example", "jti")
+			require.NoError(t, err)
+			assert.True(t, revoked)
+
+			// the same jti of another issuer, or of tokens without issuer, is another token
+			for _, issuer := range []string{"https://other.example", ""} {
+				revoked, err = store.IsRevoked(issuer, "jti")
+				require.NoError(t, err)
+				assert.False(t, revoked, issuer)
+			}
+		})
+	}
+}
+
+func TestStorePrune(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			require.NoError(t, store.Revoke("", "expired", now.Add(-2*time.Second)))
+			require.NoError(t, store.Revoke("", "valid", now.Add(time.Minute)))
+			require.NoError(t, store.Revoke("", "forever", time.Time{}))
+
+			pruned, err := store.Prune(now)
+			require.NoError(t, err)
+			assert.Equal(t, 1, pruned)
+			for jti, want := range map[string]bool{"expired": false, "valid": true, "forever": true} {
+				revoked, err := store.IsRevoked("", jti)
+				require.NoError(t, err)
+				assert.Equal(t, want, revoked, jti)
+			}
+		})
+	}
+}
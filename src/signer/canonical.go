@@ -0,0 +1,103 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// DigestAlgorithm name of the hash used for the answers digest
+const DigestAlgorithm = "sha-256"
+
+// answerPair is the canonical form of a question and its answer.
+type answerPair struct {
+	Question string `json:"q"`
+	Answer   string `json:"a"`
+}
+
+// Canonicalize encodes the question/answer pairs in their canonical form: a JSON array of {"q","a"} objects
+// in the order the questions were asked, without white space. Strings are escaped as in RFC 8785, so that verifiers
+// in any language compute the same bytes: '"' and '\\' are backslash escaped, \b, \t, \n, \f and \r use their short
+// escape, the other control characters are escaped as \u00xx in lowercase hex, and every other character, '<', '>',
+// '&', U+2028 and U+2029 included, is written as is in UTF-8.
+//
+// Parameters:
+//   - questions []string: List of questions
+//   - answers []string: List of answers corresponding to the questions
+//
+// Returns:
+//   - []byte: The canonical encoding
+//   - error: An error if questions and answers do not pair up
+func Canonicalize(questions, answers []string) ([]byte, error) {
+	pairs, err := answerPairs(questions, answers)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, p := range pairs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`{"q":`)
+		writeCanonicalString(&b, p.Question)
+		b.WriteString(`,"a":`)
+		writeCanonicalString(&b, p.Answer)
+		b.WriteByte('}')
+	}
+	b.WriteByte(']')
+	return b.Bytes(), nil
+}
+
+// writeCanonicalString writes s as a JSON string escaped as in RFC 8785. Invalid UTF-8 is replaced by U+FFFD, as
+// encoding/json does.
+func writeCanonicalString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// answerPairs pairs up the questions and their answers in the order the questions were asked.
+func answerPairs(questions, answers []string) ([]answerPair, error) {
+	if len(questions) != len(answers) {
+		return nil, fmt.Errorf("got %d questions but %d answers", len(questions), len(answers))
+	}
+	pairs := make([]answerPair, len(questions))
+	for i := range questions {
+		pairs[i] = answerPair{Question: questions[i], Answer: answers[i]}
+	}
+	return pairs, nil
+}
+
+// Digest returns the base64url encoded SHA-256 of the canonical encoding of the question/answer pairs.
+func Digest(questions, answers []string) (string, error) {
+	canonical, err := Canonicalize(questions, answers)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,58 @@
+package signer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	canonical, err := Canonicalize([]string{"question1", "question2"}, []string{"answer1", "answer2"})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"q":"question1","a":"answer1"},{"q":"question2","a":"answer2"}]`, string(canonical))
+
+	_, err = Canonicalize([]string{"question1", "question2"}, []string{"answer1"})
+	assert.Error(t, err)
+}
+
+func TestCanonicalizeEscaping(t *testing.T) {
+	// html characters are not escaped, unlike encoding/json does by default
+	canonical, err := Canonicalize([]string{"Favourite show?"}, []string{"<b>Tom & Jerry</b>"})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"q":"Favourite show?","a":"<b>Tom & Jerry</b>"}]`, string(canonical))
+	digest, err := Digest([]string{"Favourite show?"}, []string{"<b>Tom & Jerry</b>"})
+	require.NoError(t, err)
+	// SHA-256 of the canonical encoding above, computed independently
+	assert.Equal(t, "lk5p7nUGODv0YPpaUFxZqdkTWXV94jL5SdxTZKqPl5Y", digest)
+
+	canonical, err = Canonicalize([]string{"q\"\\"}, []string{"\b\t\n\f\r\x01\x1f \u2028\u2029\u00e9\x7f"})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"q":"q\"\\","a":"\b\t\n\f\r\u0001\u001f `+"\u2028\u2029\u00e9\x7f"+`"}]`, string(canonical))
+	var decoded []map[string]string
+	require.NoError(t, json.Unmarshal(canonical, &decoded))
+	assert.Equal(t, "\b\t\n\f\r\x01\x1f \u2028\u2029\u00e9\x7f", decoded[0]["a"])
+}
+
+func TestDigest(t *testing.T) {
+	// SHA-256 of the canonical encoding above, computed independently
+	digest, err := Digest([]string{"question1", "question2"}, []string{"answer1", "answer2"})
+	require.NoError(t, err)
+	assert.Equal(t, "Axg9h8kOE35iPIS80hGxoY7OQr5iiXCMadtWXE7zeEg", digest)
+
+	// the order of the questions is part of what is signed
+	swapped, err := Digest([]string{"question2", "question1"}, []string{"answer2", "answer1"})
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, swapped)
+
+	// the boundaries between questions and answers cannot be moved
+	moved, err := Digest([]string{"question1", "answer1question2"}, []string{"", "answer2"})
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, moved)
+	split, err := Digest([]string{"a", "b"}, []string{"c,d", "e"})
+	require.NoError(t, err)
+	joined, err := Digest([]string{"a", "b"}, []string{"c", "d,e"})
+	require.NoError(t, err)
+	assert.NotEqual(t, split, joined)
+}
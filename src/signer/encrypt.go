@@ -0,0 +1,88 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/square/go-jose.v2"
+	"jwt-sign/keystore"
+)
+
+// minRecipientRsaBits is the smallest RSA recipient key accepted
+const minRecipientRsaBits = 2048
+
+// Recipient is the public key a signature is encrypted to.
+type Recipient struct {
+	key       jose.JSONWebKey
+	algorithm jose.KeyAlgorithm
+}
+
+// ParseRecipient parses the JWK of the recipient of an encrypted signature. The key management algorithm is the alg
+// of the JWK, or RSA-OAEP-256 for RSA keys and ECDH-ES+A256KW for EC keys when it has none.
+//
+// Parameters:
+//   - data []byte: The JSON encoded public JWK
+//
+// Returns:
+//   - *Recipient: The recipient
+//   - error: An error if the JWK is not a public RSA or EC encryption key
+func ParseRecipient(data []byte) (*Recipient, error) {
+	var jwk jose.JSONWebKey
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, fmt.Errorf("invalid recipient key: %s", err.Error())
+	}
+	if !jwk.Valid() || !jwk.IsPublic() {
+		return nil, fmt.Errorf("recipient key must be a valid public key")
+	}
+	if jwk.Use != "" && jwk.Use != keystore.UseEncryption {
+		return nil, fmt.Errorf("recipient key is not an encryption key")
+	}
+	r := &Recipient{key: jwk, algorithm: jose.KeyAlgorithm(jwk.Algorithm)}
+	switch k := jwk.Key.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRecipientRsaBits {
+			return nil, fmt.Errorf("recipient RSA key must be at least %d bits long", minRecipientRsaBits)
+		}
+		if r.algorithm == "" {
+			r.algorithm = jose.RSA_OAEP_256
+		}
+		if r.algorithm != jose.RSA_OAEP && r.algorithm != jose.RSA_OAEP_256 {
+			return nil, fmt.Errorf("unsupported algorithm %q for a recipient RSA key", r.algorithm)
+		}
+	case *ecdsa.PublicKey:
+		if r.algorithm == "" {
+			r.algorithm = jose.ECDH_ES_A256KW
+		}
+		if r.algorithm != jose.ECDH_ES && r.algorithm != jose.ECDH_ES_A256KW {
+			return nil, fmt.Errorf("unsupported algorithm %q for a recipient EC key", r.algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported recipient key type %T", jwk.Key)
+	}
+	return r, nil
+}
+
+// Encrypt nests the compact JWS in a compact JWE readable by the recipient only, with A256GCM content encryption.
+//
+// Parameters:
+//   - signature string: The compact JWS returned by Sign
+//   - recipient *Recipient: The recipient of the signature
+//
+// Returns:
+//   - string: The compact JWE, with a JWT content type
+//   - error: An error, if any, encountered during the encryption
+func Encrypt(signature string, recipient *Recipient) (string, error) {
+	encrypter, err := jose.NewEncrypter(jose.A256GCM,
+		jose.Recipient{Algorithm: recipient.algorithm, Key: recipient.key.Key, KeyID: recipient.key.KeyID},
+		(&jose.EncrypterOptions{}).WithContentType("JWT").WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	jwe, err := encrypter.Encrypt([]byte(signature))
+	if err != nil {
+		return "", err
+	}
+	return jwe.CompactSerialize()
+}
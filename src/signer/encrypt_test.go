@@ -0,0 +1,95 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// recipientJwk encodes the key as a JWK with the algorithm and use, left out when empty.
+func recipientJwk(t *testing.T, key interface{}, alg, use string) []byte {
+	t.Helper()
+	data, err := json.Marshal(jose.JSONWebKey{Key: key, KeyID: "recipient", Algorithm: alg, Use: use})
+	require.NoError(t, err)
+	return data
+}
+
+func TestParseRecipient(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for name, test := range map[string]struct {
+		jwk       []byte
+		algorithm jose.KeyAlgorithm
+	}{
+		"rsa":      {recipientJwk(t, &rsaKey.PublicKey, "", ""), jose.RSA_OAEP_256},
+		"rsa-oaep": {recipientJwk(t, &rsaKey.PublicKey, "RSA-OAEP", "enc"), jose.RSA_OAEP},
+		"ec":       {recipientJwk(t, &ecKey.PublicKey, "", "enc"), jose.ECDH_ES_A256KW},
+		"ecdh-es":  {recipientJwk(t, &ecKey.PublicKey, "ECDH-ES", ""), jose.ECDH_ES},
+	} {
+		recipient, err := ParseRecipient(test.jwk)
+		require.NoError(t, err, name)
+		assert.Equal(t, test.algorithm, recipient.algorithm, name)
+	}
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	for name, jwk := range map[string][]byte{
+		"not json":      []byte(`{"kty":`),
+		"private key":   recipientJwk(t, rsaKey, "", ""),
+		"secret":        []byte(`{"kty":"oct","k":"c2VjcmV0"}`),
+		"signature key": recipientJwk(t, &rsaKey.PublicKey, "", "sig"),
+		"short rsa key": recipientJwk(t, &small.PublicKey, "", ""),
+		"rsa1_5":        recipientJwk(t, &rsaKey.PublicKey, "RSA1_5", ""),
+		"rsa with ecdh": recipientJwk(t, &rsaKey.PublicKey, "ECDH-ES", ""),
+		"ec with rsa":   recipientJwk(t, &ecKey.PublicKey, "RSA-OAEP-256", ""),
+		"ed25519":       recipientJwk(t, edPublic, "", ""),
+	} {
+		_, err = ParseRecipient(jwk)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	s := newTestSigner(t, nil)
+	signed, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, TokenFormatJwt)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for name, test := range map[string]struct {
+		public, private interface{}
+	}{
+		"rsa": {&rsaKey.PublicKey, rsaKey},
+		"ec":  {&ecKey.PublicKey, ecKey},
+	} {
+		recipient, err := ParseRecipient(recipientJwk(t, test.public, "", ""))
+		require.NoError(t, err, name)
+		encrypted, err := Encrypt(signed.Signature, recipient)
+		require.NoError(t, err, name)
+
+		jwe, err := jose.ParseEncrypted(encrypted)
+		require.NoError(t, err, name)
+		assert.Equal(t, "recipient", jwe.Header.KeyID, name)
+		assert.Equal(t, string(recipient.algorithm), jwe.Header.Algorithm, name)
+		assert.Equal(t, "JWT", jwe.Header.ExtraHeaders[jose.HeaderContentType], name)
+		assert.Equal(t, string(jose.A256GCM), jwe.Header.ExtraHeaders["enc"], name)
+		plaintext, err := jwe.Decrypt(test.private)
+		require.NoError(t, err, name)
+		assert.Equal(t, signed.Signature, string(plaintext), name)
+	}
+}
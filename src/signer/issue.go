@@ -0,0 +1,135 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"jwt-sign/configuration"
+)
+
+// reservedClaims are set by the issuer, or only found in answers signatures, and cannot be supplied as custom claims
+var reservedClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti", "answers_digest", "answers_digest_alg",
+	"answers_format", "_sd_alg", "answers", "answers_root", "answers_root_alg", "answers_count"}
+
+// ErrIssuancePolicy is wrapped by the errors of Issue caused by the request, any other error is a failure to sign.
+var ErrIssuancePolicy = errors.New("token request violates the issuance policy")
+
+// IssuancePolicy is the server side policy applied to issued tokens.
+type IssuancePolicy struct {
+	// DefaultTtl lifetime of a token when the request does not ask for one
+	DefaultTtl time.Duration
+	// MaxTtl upper bound of the lifetime a request may ask for
+	MaxTtl time.Duration
+	// Audiences that may be requested, any audience is accepted when empty
+	Audiences []string
+}
+
+// NewIssuancePolicy creates the issuance policy from the application configuration.
+func NewIssuancePolicy(conf *configuration.Configuration) IssuancePolicy {
+	return IssuancePolicy{
+		DefaultTtl: time.Duration(conf.TokenDefaultTtlSec) * time.Second,
+		MaxTtl:     time.Duration(conf.TokenMaxTtlSec) * time.Second,
+		Audiences:  conf.TokenAudiences,
+	}
+}
+
+// IssuedToken is a token minted by Issue.
+type IssuedToken struct {
+	Token     string
+	ExpiresAt time.Time
+	KeyId     string
+	Algorithm string
+}
+
+// Issue mints a token using the package signer initialized by Init.
+func Issue(subject string, audience []string, custom map[string]interface{}, ttl time.Duration, format string) (*IssuedToken, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("token signer is not initialized")
+	}
+	return signer.Issue(subject, audience, custom, ttl, format)
+}
+
+// Issue mints a token for the subject after applying the issuance policy.
+//
+// Parameters:
+//   - subject string: Value of the sub claim
+//   - audience []string: Values of the aud claim
+//   - custom map[string]interface{}: Additional claims, registered claims are rejected
+//   - ttl time.Duration: Requested lifetime, the policy default is used when zero
+//   - format string: One of TokenFormats, a JWT when empty
+//
+// Returns:
+//   - *IssuedToken: The signed token along with its expiry and the key that signed it
+//   - error: An error wrapping ErrIssuancePolicy if the request violates the policy, or an error if the token cannot be
+//     signed
+func (s *Signer) Issue(subject string, audience []string, custom map[string]interface{}, ttl time.Duration, format string) (*IssuedToken, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("%w: cannot issue a token without a subject", ErrIssuancePolicy)
+	}
+	if ttl == 0 {
+		ttl = s.policy.DefaultTtl
+	}
+	if ttl < 0 || ttl > s.policy.MaxTtl {
+		return nil, fmt.Errorf("%w: ttl must be between 1 and %d seconds", ErrIssuancePolicy, int64(s.policy.MaxTtl/time.Second))
+	}
+	if format != "" && !contains(TokenFormats, format) {
+		return nil, fmt.Errorf("%w: tokens cannot be issued as %s", ErrIssuancePolicy, format)
+	}
+	if len(s.policy.Audiences) > 0 {
+		for _, a := range audience {
+			if !contains(s.policy.Audiences, a) {
+				return nil, fmt.Errorf("%w: audience %q is not allowed", ErrIssuancePolicy, a)
+			}
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := jwt.MapClaims{}
+	for name, value := range custom {
+		if contains(reservedClaims, name) {
+			return nil, fmt.Errorf("%w: claim %s is reserved", ErrIssuancePolicy, name)
+		}
+		claims[name] = value
+	}
+	claims["iss"] = s.issuer
+	claims["sub"] = subject
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+	claims["jti"] = uuid.New().String()
+	if len(audience) == 1 {
+		claims["aud"] = audience[0]
+	} else if len(audience) > 1 {
+		claims["aud"] = audience
+	}
+
+	if format != "" && format != TokenFormatJwt {
+		token, kid, err := s.signPaseto(claims, format, TypeAccessToken)
+		if err != nil {
+			return nil, err
+		}
+		return &IssuedToken{Token: token, ExpiresAt: time.Unix(expiresAt.Unix(), 0), KeyId: kid, Algorithm: format}, nil
+	}
+	key, err := s.tokenSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := signClaims(claims, key, TypeAccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &IssuedToken{Token: signed, ExpiresAt: time.Unix(expiresAt.Unix(), 0), KeyId: key.Id, Algorithm: key.Algorithm}, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
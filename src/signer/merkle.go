@@ -0,0 +1,183 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// TokenFormatMerkle format of answers signatures over the root of a Merkle tree of the salted answers, handed out with
+// an inclusion proof per answer. Tokens cannot be issued in this format.
+const TokenFormatMerkle = "merkle"
+
+// Prefixes of the leaf and node hashes of the Merkle tree, as in RFC 9162, so that a node cannot pass for a leaf.
+const (
+	leafHashPrefix = 0x00
+	nodeHashPrefix = 0x01
+)
+
+// InclusionProof proves that one salted answer is a leaf of the Merkle tree whose root is signed.
+type InclusionProof struct {
+	// Index of the leaf, in the order of the answers
+	Index int
+	// Leaf is the base64url encoded JSON array [salt, answer], the answer in its canonical form
+	Leaf string
+	// Path lists the base64url encoded sibling hashes from the leaf up to the root
+	Path []string
+}
+
+// signMerkle builds a Merkle tree over the salted items and signs its root bound to the subject.
+//
+// Parameters:
+//   - subject string: Subject of the presented JWT the answers belong to
+//   - items []interface{}: The answers in their canonical form, answerPair or structuredAnswer
+//   - answersFormat string: How the answers were canonicalized, empty for question/answer pairs
+//
+// Returns:
+//   - *SignedAnswers: The compact JWS over the root with the inclusion proofs of the items, its claims and the key id
+//     and algorithm
+//   - error: An error, if any, encountered during the signing process
+func (s *Signer) signMerkle(subject string, items []interface{}, answersFormat string) (*SignedAnswers, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("cannot build a Merkle tree without answers")
+	}
+	proofs := make([]InclusionProof, len(items))
+	hashes := make([][]byte, len(items))
+	for i, item := range items {
+		salt := make([]byte, disclosureSaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		leaf, err := json.Marshal([]interface{}{base64.RawURLEncoding.EncodeToString(salt), item})
+		if err != nil {
+			return nil, err
+		}
+		proofs[i] = InclusionProof{Index: i, Leaf: base64.RawURLEncoding.EncodeToString(leaf)}
+		hashes[i] = leafHash(leaf)
+	}
+	paths := make([][][]byte, len(items))
+	root := merkleRoot(hashes, paths, 0)
+	for i, path := range paths {
+		proofs[i].Path = make([]string, len(path))
+		for j, h := range path {
+			proofs[i].Path[j] = base64.RawURLEncoding.EncodeToString(h)
+		}
+	}
+	claims := AnswersClaims{
+		AnswersRoot:    base64.RawURLEncoding.EncodeToString(root),
+		AnswersRootAlg: DigestAlgorithm,
+		AnswersCount:   len(items),
+		AnswersFormat:  answersFormat,
+	}
+	signed, err := s.signAnswers(subject, claims, TokenFormatJwt)
+	if err != nil {
+		return nil, err
+	}
+	signed.Proofs = proofs
+	return signed, nil
+}
+
+// merkleRoot returns the root of the tree over the leaf hashes, split as in RFC 9162 at the largest power of two
+// smaller than the number of leaves, and appends the sibling hashes of every leaf to its path, bottom up. offset is
+// the index of the first leaf within paths.
+func merkleRoot(hashes [][]byte, paths [][][]byte, offset int) []byte {
+	n := len(hashes)
+	if n == 1 {
+		return hashes[0]
+	}
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+	left := merkleRoot(hashes[:k], paths, offset)
+	right := merkleRoot(hashes[k:], paths, offset+k)
+	for i := offset; i < offset+k; i++ {
+		paths[i] = append(paths[i], right)
+	}
+	for i := offset + k; i < offset+n; i++ {
+		paths[i] = append(paths[i], left)
+	}
+	return nodeHash(left, right)
+}
+
+// leafHash returns the hash of a leaf of the Merkle tree.
+func leafHash(leaf []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafHashPrefix})
+	h.Write(leaf)
+	return h.Sum(nil)
+}
+
+// nodeHash returns the hash of an inner node of the Merkle tree.
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodeHashPrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// VerifyInclusion checks that the proof leads from its leaf to the Merkle root carried by verified answers claims,
+// following the inclusion proof verification of RFC 9162.
+//
+// Parameters:
+//   - claims AnswersClaims: The claims of a verified answers signature
+//   - proof InclusionProof: The inclusion proof of one answer
+//
+// Returns:
+//   - *DisclosedAnswer: The answer the leaf holds
+//   - error: An error if the signature has no Merkle root or the proof does not lead to it
+func VerifyInclusion(claims AnswersClaims, proof InclusionProof) (*DisclosedAnswer, error) {
+	if claims.AnswersRoot == "" || claims.AnswersRootAlg != DigestAlgorithm {
+		return nil, fmt.Errorf("signature has no answers root")
+	}
+	root, err := base64.RawURLEncoding.Strict().DecodeString(claims.AnswersRoot)
+	if err != nil {
+		return nil, fmt.Errorf("answers root is not base64url encoded")
+	}
+	if proof.Index < 0 || proof.Index >= claims.AnswersCount {
+		return nil, fmt.Errorf("leaf index %d is out of the %d answers", proof.Index, claims.AnswersCount)
+	}
+	leaf, err := base64.RawURLEncoding.Strict().DecodeString(proof.Leaf)
+	if err != nil {
+		return nil, fmt.Errorf("leaf is not base64url encoded")
+	}
+	if err = verifyPath(leafHash(leaf), proof.Index, claims.AnswersCount, proof.Path, root); err != nil {
+		return nil, err
+	}
+	return decodeSaltedAnswer(proof.Leaf, claims.AnswersFormat)
+}
+
+// verifyPath checks that the base64url encoded sibling hashes lead from the hash of the leaf at index to the root of a
+// tree of size leaves.
+func verifyPath(hash []byte, index, size int, path []string, root []byte) error {
+	fn, sn := index, size-1
+	r := hash
+	for _, encoded := range path {
+		p, err := base64.RawURLEncoding.Strict().DecodeString(encoded)
+		if err != nil || len(p) != sha256.Size {
+			return fmt.Errorf("proof path holds an invalid hash")
+		}
+		if sn == 0 {
+			return fmt.Errorf("proof path is too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(r, root) {
+		return fmt.Errorf("proof does not lead to the answers root")
+	}
+	return nil
+}
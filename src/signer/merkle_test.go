@@ -0,0 +1,223 @@
+package signer
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors of RFC 9162, as published with the certificate transparency test data: the leaves, the roots of the
+// trees over their first n leaves and some inclusion proofs.
+var (
+	vectorLeaves = []string{"", "00", "10", "2021", "3031", "40414243", "5051525354555657", "606162636465666768696a6b6c6d6e6f"}
+	vectorRoots  = []string{
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+	vectorProofs = []struct {
+		index, size int
+		path        []string
+	}{
+		{0, 1, nil},
+		{0, 8, []string{
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+		}},
+		{5, 8, []string{
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		}},
+		{2, 3, []string{
+			"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		}},
+		{1, 5, []string{
+			"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		}},
+	}
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// encodePath converts hex encoded hashes to the base64url encoding of the proofs.
+func encodePath(t *testing.T, path []string) []string {
+	t.Helper()
+	encoded := make([]string, len(path))
+	for i, h := range path {
+		encoded[i] = base64.RawURLEncoding.EncodeToString(decodeHex(t, h))
+	}
+	return encoded
+}
+
+func vectorLeafHashes(t *testing.T) [][]byte {
+	t.Helper()
+	hashes := make([][]byte, len(vectorLeaves))
+	for i, leaf := range vectorLeaves {
+		hashes[i] = leafHash(decodeHex(t, leaf))
+	}
+	return hashes
+}
+
+func TestMerkleRootVectors(t *testing.T) {
+	hashes := vectorLeafHashes(t)
+	for n := 1; n <= len(hashes); n++ {
+		paths := make([][][]byte, n)
+		root := merkleRoot(hashes[:n], paths, 0)
+		assert.Equal(t, vectorRoots[n-1], hex.EncodeToString(root), "size %d", n)
+
+		// every path built along the root leads back to it
+		for i, path := range paths {
+			encoded := make([]string, len(path))
+			for j, h := range path {
+				encoded[j] = base64.RawURLEncoding.EncodeToString(h)
+			}
+			assert.NoError(t, verifyPath(hashes[i], i, n, encoded, root), "leaf %d of %d", i, n)
+		}
+	}
+}
+
+func TestInclusionProofVectors(t *testing.T) {
+	hashes := vectorLeafHashes(t)
+	for _, v := range vectorProofs {
+		root := decodeHex(t, vectorRoots[v.size-1])
+		path := encodePath(t, v.path)
+		assert.NoError(t, verifyPath(hashes[v.index], v.index, v.size, path, root), "leaf %d of %d", v.index, v.size)
+
+		paths := make([][][]byte, v.size)
+		merkleRoot(hashes[:v.size], paths, 0)
+		assert.Equal(t, v.path, hexPath(paths[v.index]), "leaf %d of %d", v.index, v.size)
+
+		// the proof of a leaf proves no other leaf, nor the leaf at another index
+		other := (v.index + 1) % len(hashes)
+		assert.Error(t, verifyPath(hashes[other], v.index, v.size, path, root), "leaf %d of %d", v.index, v.size)
+		if v.size > 1 {
+			assert.Error(t, verifyPath(hashes[v.index], (v.index+1)%v.size, v.size, path, root), "leaf %d of %d", v.index, v.size)
+		}
+	}
+}
+
+func hexPath(path [][]byte) []string {
+	if path == nil {
+		return nil
+	}
+	encoded := make([]string, len(path))
+	for i, h := range path {
+		encoded[i] = hex.EncodeToString(h)
+	}
+	return encoded
+}
+
+func TestVerifyPathRejectsMalformedProofs(t *testing.T) {
+	hashes := vectorLeafHashes(t)
+	v := vectorProofs[2]
+	root := decodeHex(t, vectorRoots[v.size-1])
+	path := encodePath(t, v.path)
+
+	// the siblings in another order
+	swapped := []string{path[1], path[0], path[2]}
+	assert.EqualError(t, verifyPath(hashes[v.index], v.index, v.size, swapped, root), "proof does not lead to the answers root")
+	// a sibling missing, or one too many
+	assert.Error(t, verifyPath(hashes[v.index], v.index, v.size, path[:2], root))
+	assert.EqualError(t, verifyPath(hashes[v.index], v.index, v.size, append(path, path[0]), root), "proof path is too long")
+	// a proof for another tree size
+	assert.Error(t, verifyPath(hashes[v.index], v.index, v.size-2, path, root))
+	// hashes that are not SHA-256
+	assert.EqualError(t, verifyPath(hashes[v.index], v.index, v.size, []string{path[0], path[1], path[2][:10]}, root),
+		"proof path holds an invalid hash")
+	assert.EqualError(t, verifyPath(hashes[v.index], v.index, v.size, []string{path[0], path[1], path[2] + "="}, root),
+		"proof path holds an invalid hash")
+}
+
+func TestSignMerkle(t *testing.T) {
+	s := newTestSigner(t, nil)
+	for _, n := range []int{1, 2, 3, 5, 7, 8} {
+		questions, answers := make([]string, n), make([]string, n)
+		for i := range questions {
+			questions[i] = "question" + string(rune('a'+i))
+			answers[i] = "answer" + string(rune('a'+i))
+		}
+		signed, err := s.Sign("JonnyBoy", questions, answers, TokenFormatMerkle)
+		require.NoError(t, err, n)
+		require.Len(t, signed.Proofs, n)
+		verification, err := s.Verify(signed.Signature)
+		require.NoError(t, err, n)
+		assert.Equal(t, n, verification.Claims.AnswersCount)
+
+		for i, proof := range signed.Proofs {
+			answer, err := VerifyInclusion(verification.Claims, proof)
+			require.NoError(t, err, "leaf %d of %d", i, n)
+			assert.Equal(t, &DisclosedAnswer{Question: questions[i], Value: answers[i]}, answer)
+		}
+	}
+}
+
+func TestVerifyInclusionRejectsTampering(t *testing.T) {
+	s := newTestSigner(t, nil)
+	signed, err := s.Sign("JonnyBoy", []string{"question1", "question2", "question3"}, []string{"answer1", "answer2", "answer3"}, TokenFormatMerkle)
+	require.NoError(t, err)
+	claims := signed.Claims
+	proof := signed.Proofs[1]
+	_, err = VerifyInclusion(claims, proof)
+	require.NoError(t, err)
+
+	// out of range indexes
+	for _, index := range []int{-1, 3, 4} {
+		tampered := proof
+		tampered.Index = index
+		_, err = VerifyInclusion(claims, tampered)
+		assert.Error(t, err, index)
+	}
+
+	// the leaf of another index
+	tampered := proof
+	tampered.Index = 0
+	_, err = VerifyInclusion(claims, tampered)
+	assert.EqualError(t, err, "proof does not lead to the answers root")
+
+	// another salt, or another answer under the same salt
+	data, err := base64.RawURLEncoding.DecodeString(proof.Leaf)
+	require.NoError(t, err)
+	var leaf []interface{}
+	require.NoError(t, json.Unmarshal(data, &leaf))
+	for name, element := range map[string][]interface{}{
+		"salt":   {base64.RawURLEncoding.EncodeToString(make([]byte, disclosureSaltLength)), leaf[1]},
+		"answer": {leaf[0], map[string]string{"q": "question2", "a": "forged"}},
+	} {
+		data, err := json.Marshal(element)
+		require.NoError(t, err)
+		tampered := proof
+		tampered.Leaf = base64.RawURLEncoding.EncodeToString(data)
+		_, err = VerifyInclusion(claims, tampered)
+		assert.EqualError(t, err, "proof does not lead to the answers root", name)
+	}
+
+	// the siblings in reverse order
+	tampered = signed.Proofs[0]
+	tampered.Path = []string{tampered.Path[1], tampered.Path[0]}
+	_, err = VerifyInclusion(claims, tampered)
+	assert.EqualError(t, err, "proof does not lead to the answers root")
+
+	// a signature without a root
+	digest, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, TokenFormatJwt)
+	require.NoError(t, err)
+	_, err = VerifyInclusion(digest.Claims, proof)
+	assert.EqualError(t, err, "signature has no answers root")
+}
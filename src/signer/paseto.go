@@ -0,0 +1,199 @@
+package signer
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"jwt-sign/configuration"
+	"jwt-sign/keystore"
+	"jwt-sign/paseto"
+)
+
+// TokenFormatJwt format of compact JWS tokens and signatures. The PASETO formats are named after their version and
+// purpose, paseto.PurposePublic and paseto.PurposeLocal.
+const TokenFormatJwt = "jwt"
+
+// answersAssertion is the implicit assertion of PASETO answers signatures. It is authenticated with the signature, so
+// that an answers signature cannot be verified as a token nor a token as an answers signature.
+var answersAssertion = []byte(TypeAnswers)
+
+// TokenFormats are the formats tokens and answers signatures can be produced in.
+var TokenFormats = []string{TokenFormatJwt, paseto.PurposePublic, paseto.PurposeLocal}
+
+// FormatPolicy selects the format of answers signatures from the issuer of the presented token.
+type FormatPolicy struct {
+	// Default format of the signatures
+	Default string
+	// Issuers maps the issuer of a token to the format of the signatures of its answers, in place of Default
+	Issuers map[string]string
+}
+
+// NewFormatPolicy creates the format policy from the application configuration.
+//
+// Parameters:
+//   - conf *configuration.Configuration: application configuration holding the signature formats
+//
+// Returns:
+//   - FormatPolicy: The policy
+//   - error: An error if a format is unknown or an issuer entry is malformed
+func NewFormatPolicy(conf *configuration.Configuration) (FormatPolicy, error) {
+	p := FormatPolicy{Default: conf.SignatureFormat, Issuers: map[string]string{}}
+	if p.Default == "" {
+		p.Default = TokenFormatJwt
+	}
+	if !contains(SignatureFormats, p.Default) {
+		return p, fmt.Errorf("unsupported signature format %q", p.Default)
+	}
+	for _, entry := range conf.SignatureFormatIssuers {
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 || !contains(SignatureFormats, entry[i+1:]) {
+			return p, fmt.Errorf("signature format %q is not in the issuer=FORMAT form", entry)
+		}
+		p.Issuers[entry[:i]] = entry[i+1:]
+	}
+	return p, nil
+}
+
+// Format returns the format of the signatures of the answers presented with a token of the issuer.
+func (p FormatPolicy) Format(issuer string) string {
+	if format, ok := p.Issuers[issuer]; ok {
+		return format
+	}
+	return p.Default
+}
+
+// SignatureFormat returns the format of answers signatures for the issuer, using the package signer initialized by
+// Init.
+func SignatureFormat(issuer string) string {
+	if signer == nil {
+		return TokenFormatJwt
+	}
+	return signer.formats.Format(issuer)
+}
+
+// signPaseto produces a PASETO of the format over the claims, whose date claims are converted to RFC 3339 strings.
+//
+// Parameters:
+//   - claims interface{}: The claims, marshalled to a JSON object
+//   - format string: paseto.PurposePublic or paseto.PurposeLocal
+//   - typ string: TypeAccessToken or TypeAnswers, selects the key and the implicit assertion
+//
+// Returns:
+//   - string: The token
+//   - string: The kid of the Ed25519 signing key, empty for v4.local tokens
+//   - error: An error if no key is available for the format
+func (s *Signer) signPaseto(claims interface{}, format, typ string) (string, string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", "", err
+	}
+	var payload map[string]interface{}
+	if err = json.Unmarshal(data, &payload); err != nil {
+		return "", "", err
+	}
+	paseto.FromNumericDates(payload)
+	if data, err = json.Marshal(payload); err != nil {
+		return "", "", err
+	}
+	localKey, implicit := s.pasetoKey, []byte(nil)
+	if typ == TypeAnswers {
+		localKey, implicit = s.answersPasetoKey, answersAssertion
+	}
+	switch format {
+	case paseto.PurposePublic:
+		key, err := s.pasetoSigningKey(typ)
+		if err != nil {
+			return "", "", err
+		}
+		footer, err := json.Marshal(paseto.KeyFooter{KeyId: key.Id})
+		if err != nil {
+			return "", "", err
+		}
+		return paseto.Sign(key.Private.(ed25519.PrivateKey), data, footer, implicit), key.Id, nil
+	case paseto.PurposeLocal:
+		if localKey == nil {
+			return "", "", fmt.Errorf("no paseto local key configured for %s", typ)
+		}
+		token, err := paseto.Encrypt(localKey, data, nil, implicit)
+		return token, "", err
+	}
+	return "", "", fmt.Errorf("unsupported token format %q", format)
+}
+
+// pasetoSigningKey returns the Ed25519 key signing v4.public tokens of the typ. Tokens are signed by the token
+// signing key when one is configured. Otherwise the signing key is used when it is an Ed25519 key, or else the first
+// active Ed25519 signing key of the key store that is not the token signing key.
+func (s *Signer) pasetoSigningKey(typ string) (*keystore.Key, error) {
+	if typ == TypeAccessToken && s.tokenKeyId != "" {
+		key, err := s.tokenSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := key.Private.(ed25519.PrivateKey); !ok {
+			return nil, fmt.Errorf("token signing key %q is not an Ed25519 key", key.Id)
+		}
+		return key, nil
+	}
+	if key, err := s.keys.SigningKey(); err == nil && key.Algorithm == "EdDSA" {
+		return key, nil
+	}
+	for _, key := range s.keys.Keys() {
+		if _, ok := key.Private.(ed25519.PrivateKey); ok && key.CanSign() && key.RetiredAt.IsZero() && key.Id != s.tokenKeyId {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no Ed25519 signing key for %s tokens", paseto.PurposePublic)
+}
+
+// verifyPaseto verifies a v4.public or decrypts a v4.local answers signature, bound to the answers assertion.
+func (s *Signer) verifyPaseto(signature string) (*Verification, error) {
+	purpose := paseto.Purpose(signature)
+	footer, err := paseto.Footer(signature)
+	if err != nil {
+		return nil, err
+	}
+	kid := paseto.KeyId(footer)
+	var payload []byte
+	switch purpose {
+	case paseto.PurposePublic:
+		var key *keystore.Key
+		if key, err = s.answersKey(kid); err != nil {
+			return nil, err
+		}
+		public, ok := key.Public.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("key %q is not an Ed25519 signature key", kid)
+		}
+		payload, err = paseto.Verify(public, signature, answersAssertion)
+	case paseto.PurposeLocal:
+		if s.answersPasetoKey == nil {
+			return nil, fmt.Errorf("no paseto local key configured for %s", TypeAnswers)
+		}
+		payload, err = paseto.Decrypt(s.answersPasetoKey, signature, answersAssertion)
+	default:
+		return nil, paseto.ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+	if err = paseto.ToNumericDates(claims); err != nil {
+		return nil, err
+	}
+	var answers AnswersClaims
+	if payload, err = json.Marshal(claims); err == nil {
+		err = json.Unmarshal(payload, &answers)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = answers.validate(); err != nil {
+		return nil, err
+	}
+	return &Verification{Claims: answers, KeyId: kid, Algorithm: purpose}, nil
+}
@@ -0,0 +1,211 @@
+package signer
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"jwt-sign/paseto"
+)
+
+// TokenFormatSdJwt format of answers signatures issued as an SD-JWT, where every answer is a separately disclosable
+// element of the answers claim. Tokens cannot be issued in this format.
+const TokenFormatSdJwt = "sd-jwt"
+
+// SignatureFormats are the formats answers signatures can be produced in.
+var SignatureFormats = append(append([]string{}, TokenFormats...), TokenFormatSdJwt, TokenFormatMerkle)
+
+// disclosureSaltLength number of random bytes salting every disclosure or Merkle leaf, so that an undisclosed answer
+// cannot be guessed from its digest.
+const disclosureSaltLength = 16
+
+// disclosureSeparator separates the issuer signed JWT and the disclosures of an SD-JWT.
+const disclosureSeparator = "~"
+
+// DisclosureDigest is an element of the answers claim of an SD-JWT, the digest of the disclosure of one answer.
+type DisclosureDigest struct {
+	Digest string `json:"..."`
+}
+
+// Token returns the signature as handed out: the compact JWS or the PASETO, or the issuer signed JWT followed by all
+// the disclosures for an SD-JWT.
+func (s *SignedAnswers) Token() string {
+	if s.Disclosures == nil {
+		return s.Signature
+	}
+	return s.Signature + disclosureSeparator + strings.Join(s.Disclosures, disclosureSeparator) + disclosureSeparator
+}
+
+// signDisclosures produces an SD-JWT binding the items to the subject. Every item is disclosed as an element of the
+// answers claim, in the order of the items.
+//
+// Parameters:
+//   - subject string: Subject of the presented JWT the answers belong to
+//   - items []interface{}: The answers in their canonical form, answerPair or structuredAnswer
+//   - answersFormat string: How the answers were canonicalized, empty for question/answer pairs
+//
+// Returns:
+//   - *SignedAnswers: The issuer signed JWT and its disclosures, with its claims and the key id and algorithm
+//   - error: An error, if any, encountered during the signing process
+func (s *Signer) signDisclosures(subject string, items []interface{}, answersFormat string) (*SignedAnswers, error) {
+	disclosures := make([]string, len(items))
+	digests := make([]DisclosureDigest, len(items))
+	for i, item := range items {
+		salt := make([]byte, disclosureSaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal([]interface{}{base64.RawURLEncoding.EncodeToString(salt), item})
+		if err != nil {
+			return nil, err
+		}
+		disclosures[i] = base64.RawURLEncoding.EncodeToString(data)
+		digests[i] = DisclosureDigest{Digest: disclosureDigest(disclosures[i])}
+	}
+	claims := AnswersClaims{SdAlg: DigestAlgorithm, Answers: digests, AnswersFormat: answersFormat}
+	signed, err := s.signAnswers(subject, claims, TokenFormatJwt)
+	if err != nil {
+		return nil, err
+	}
+	signed.Disclosures = disclosures
+	return signed, nil
+}
+
+// disclosureDigest returns the base64url encoded SHA-256 of the disclosure, as it is listed in the answers claim.
+func disclosureDigest(disclosure string) string {
+	sum := sha256.Sum256([]byte(disclosure))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// DisclosedAnswer is an answer revealed by a presentation: a question and its answer, or a question id and its typed
+// answer for structured answers.
+type DisclosedAnswer struct {
+	Question   string
+	QuestionId string
+	Value      interface{}
+}
+
+// Presentation is the result of a successful verification of an SD-JWT presentation.
+type Presentation struct {
+	Verification
+	// Disclosed answers, in the order of the answers claim
+	Disclosed []DisclosedAnswer
+}
+
+// VerifyPresentation verifies a presentation using the package signer initialized by Init.
+func VerifyPresentation(presentation string) (*Presentation, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("answers signer is not initialized")
+	}
+	return signer.VerifyPresentation(presentation)
+}
+
+// VerifyPresentation checks that the issuer signed JWT of the SD-JWT presentation was produced by a key of the key
+// store, that its answers claim lists every digest once and that every disclosure it carries is listed there.
+// Presentations may carry any subset of the disclosures, key binding is not supported.
+//
+// Parameters:
+//   - presentation string: The issuer signed JWT followed by the disclosures, each of them terminated by a tilde
+//
+// Returns:
+//   - *Presentation: The verified claims with the disclosed answers, and the key id and algorithm that verified them
+//   - error: An error if the presentation is malformed, does not verify or carries a disclosure it does not list
+func (s *Signer) VerifyPresentation(presentation string) (*Presentation, error) {
+	parts := strings.Split(presentation, disclosureSeparator)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("presentation is not an SD-JWT")
+	}
+	if parts[len(parts)-1] != "" {
+		return nil, fmt.Errorf("key binding is not supported")
+	}
+	if paseto.IsToken(parts[0]) {
+		return nil, fmt.Errorf("presentation is not an SD-JWT")
+	}
+	verification, err := s.Verify(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if verification.Claims.SdAlg != DigestAlgorithm || len(verification.Claims.Answers) == 0 {
+		return nil, fmt.Errorf("signature has no disclosable answers")
+	}
+
+	positions := make(map[string]int, len(verification.Claims.Answers))
+	for i, d := range verification.Claims.Answers {
+		if _, ok := positions[d.Digest]; ok {
+			return nil, fmt.Errorf("signature lists a disclosure more than once")
+		}
+		positions[d.Digest] = i
+	}
+	disclosed := make([]*DisclosedAnswer, len(verification.Claims.Answers))
+	for _, disclosure := range parts[1 : len(parts)-1] {
+		i, ok := positions[disclosureDigest(disclosure)]
+		if !ok {
+			return nil, fmt.Errorf("disclosure is not listed in the signature")
+		}
+		if disclosed[i] != nil {
+			return nil, fmt.Errorf("disclosure is presented more than once")
+		}
+		if disclosed[i], err = decodeSaltedAnswer(disclosure, verification.Claims.AnswersFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Presentation{Verification: *verification, Disclosed: []DisclosedAnswer{}}
+	for _, answer := range disclosed {
+		if answer != nil {
+			result.Disclosed = append(result.Disclosed, *answer)
+		}
+	}
+	return result, nil
+}
+
+// decodeSaltedAnswer decodes an array element disclosure or a Merkle leaf, the base64url encoded [salt, answer], where
+// the answer is in the canonical form of the answers format.
+func decodeSaltedAnswer(encoded, answersFormat string) (*DisclosedAnswer, error) {
+	data, err := base64.RawURLEncoding.Strict().DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("salted answer is not base64url encoded")
+	}
+	var element []json.RawMessage
+	if err = json.Unmarshal(data, &element); err != nil || len(element) != 2 {
+		return nil, fmt.Errorf("salted answer is not a [salt, answer] array")
+	}
+	if answersFormat == FormatStructured {
+		var item structuredAnswer
+		if err = json.Unmarshal(element[1], &item); err != nil || item.QuestionId == "" {
+			return nil, fmt.Errorf("salted answer does not hold a structured answer")
+		}
+		value, err := typedAnswer(item.Answer)
+		if err != nil {
+			return nil, err
+		}
+		return &DisclosedAnswer{QuestionId: item.QuestionId, Value: value}, nil
+	}
+	var pair answerPair
+	if err = json.Unmarshal(element[1], &pair); err != nil {
+		return nil, fmt.Errorf("salted answer does not hold a question/answer pair")
+	}
+	return &DisclosedAnswer{Question: pair.Question, Value: pair.Answer}, nil
+}
+
+// typedAnswer converts a decoded JSON answer back to one of the types of Answer.
+func typedAnswer(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string, float64, bool:
+		return v, nil
+	case []interface{}:
+		choices := make([]string, len(v))
+		for i, choice := range v {
+			s, ok := choice.(string)
+			if !ok {
+				return nil, fmt.Errorf("disclosed choices must be strings")
+			}
+			choices[i] = s
+		}
+		return choices, nil
+	}
+	return nil, fmt.Errorf("disclosed answer has unsupported type %T", value)
+}
@@ -0,0 +1,208 @@
+package signer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// present builds a presentation of the issuer signed JWT carrying the disclosures, in that order.
+func present(signature string, disclosures ...string) string {
+	return signature + disclosureSeparator + strings.Join(append(disclosures, ""), disclosureSeparator)
+}
+
+// disclose encodes a disclosure of the answer, salted with salt.
+func disclose(t *testing.T, salt string, answer interface{}) string {
+	t.Helper()
+	data, err := json.Marshal([]interface{}{salt, answer})
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// otherCharacter returns a base64url character other than c.
+func otherCharacter(c string) string {
+	if c == "A" {
+		return "B"
+	}
+	return "A"
+}
+
+func signSdJwt(t *testing.T, s *Signer) *SignedAnswers {
+	t.Helper()
+	signed, err := s.Sign("JonnyBoy", []string{"question1", "question2", "question3"}, []string{"answer1", "answer2", "answer3"}, TokenFormatSdJwt)
+	require.NoError(t, err)
+	require.Len(t, signed.Disclosures, 3)
+	return signed
+}
+
+func TestVerifyPresentation(t *testing.T) {
+	s := newTestSigner(t, nil)
+	signed := signSdJwt(t, s)
+
+	presentation, err := s.VerifyPresentation(signed.Token())
+	require.NoError(t, err)
+	assert.Equal(t, "JonnyBoy", presentation.Claims.Subject)
+	assert.Equal(t, []DisclosedAnswer{
+		{Question: "question1", Value: "answer1"},
+		{Question: "question2", Value: "answer2"},
+		{Question: "question3", Value: "answer3"},
+	}, presentation.Disclosed)
+
+	// any subset may be presented in any order, the answers keep the order of the signature
+	presentation, err = s.VerifyPresentation(present(signed.Signature, signed.Disclosures[2], signed.Disclosures[0]))
+	require.NoError(t, err)
+	assert.Equal(t, []DisclosedAnswer{{Question: "question1", Value: "answer1"}, {Question: "question3", Value: "answer3"}}, presentation.Disclosed)
+
+	presentation, err = s.VerifyPresentation(present(signed.Signature))
+	require.NoError(t, err)
+	assert.Empty(t, presentation.Disclosed)
+}
+
+func TestVerifyStructuredPresentation(t *testing.T) {
+	s := newTestSigner(t, nil)
+	signed, err := s.SignStructured("JonnyBoy", []Answer{
+		{QuestionId: "q1", Value: "text"},
+		{QuestionId: "q2", Value: 4.5},
+		{QuestionId: "q3", Value: true},
+		{QuestionId: "q4", Value: []string{"a", "b"}},
+	}, TokenFormatSdJwt)
+	require.NoError(t, err)
+
+	presentation, err := s.VerifyPresentation(signed.Token())
+	require.NoError(t, err)
+	assert.Equal(t, []DisclosedAnswer{
+		{QuestionId: "q1", Value: "text"},
+		{QuestionId: "q2", Value: 4.5},
+		{QuestionId: "q3", Value: true},
+		{QuestionId: "q4", Value: []string{"a", "b"}},
+	}, presentation.Disclosed)
+}
+
+func TestVerifyPresentationRejectsTamperedDisclosures(t *testing.T) {
+	s := newTestSigner(t, nil)
+	signed := signSdJwt(t, s)
+
+	// the same salt with another answer
+	data, err := base64.RawURLEncoding.DecodeString(signed.Disclosures[0])
+	require.NoError(t, err)
+	var element []interface{}
+	require.NoError(t, json.Unmarshal(data, &element))
+	forged := disclose(t, element[0].(string), map[string]string{"q": "question1", "a": "forged"})
+	truncated := signed.Disclosures[0][:len(signed.Disclosures[0])-1]
+
+	for name, disclosure := range map[string]string{
+		"other answer": forged,
+		"re-encoded":   base64.RawURLEncoding.EncodeToString(append(data, ' ')),
+		"padded":       signed.Disclosures[0] + "=",
+		"truncated":    truncated,
+		"last changed": truncated + otherCharacter(signed.Disclosures[0][len(truncated):]),
+	} {
+		_, err = s.VerifyPresentation(present(signed.Signature, signed.Disclosures[1], disclosure))
+		assert.EqualError(t, err, "disclosure is not listed in the signature", name)
+	}
+
+	// the issuer signed JWT covers the digests
+	parts := strings.Split(signed.Signature, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"JonnyBoy","_sd_alg":"sha-256","answers":[{"...":"` +
+		disclosureDigest(forged) + `"}]}`))
+	_, err = s.VerifyPresentation(present(strings.Join(parts, "."), forged))
+	assert.Error(t, err)
+}
+
+func TestVerifyPresentationRejectsDuplicates(t *testing.T) {
+	s := newTestSigner(t, nil)
+	signed := signSdJwt(t, s)
+
+	_, err := s.VerifyPresentation(present(signed.Signature, signed.Disclosures[0], signed.Disclosures[1], signed.Disclosures[0]))
+	assert.EqualError(t, err, "disclosure is presented more than once")
+
+	// a signature listing the same digest twice
+	key, err := s.keys.SigningKey()
+	require.NoError(t, err)
+	digest := disclosureDigest(signed.Disclosures[0])
+	claims := AnswersClaims{
+		StandardClaims: jwt.StandardClaims{Subject: "JonnyBoy"},
+		SdAlg:          DigestAlgorithm,
+		Answers:        []DisclosureDigest{{Digest: digest}, {Digest: disclosureDigest(signed.Disclosures[1])}, {Digest: digest}},
+	}
+	signature, err := signClaims(claims, key, TypeAnswers)
+	require.NoError(t, err)
+	_, err = s.VerifyPresentation(present(signature, signed.Disclosures[0]))
+	assert.EqualError(t, err, "signature lists a disclosure more than once")
+}
+
+func TestVerifyPresentationRejectsUnreferencedDisclosures(t *testing.T) {
+	s := newTestSigner(t, nil)
+	signed := signSdJwt(t, s)
+	other := signSdJwt(t, s)
+
+	// a disclosure of another signature of the same answers has another salt
+	_, err := s.VerifyPresentation(present(signed.Signature, signed.Disclosures[0], other.Disclosures[1]))
+	assert.EqualError(t, err, "disclosure is not listed in the signature")
+	_, err = s.VerifyPresentation(present(signed.Signature, disclose(t, "salt", map[string]string{"q": "question4", "a": "answer4"})))
+	assert.EqualError(t, err, "disclosure is not listed in the signature")
+}
+
+func TestVerifyPresentationRejectsWrongDigestAlgorithm(t *testing.T) {
+	s := newTestSigner(t, nil)
+	signed := signSdJwt(t, s)
+	key, err := s.keys.SigningKey()
+	require.NoError(t, err)
+
+	for _, alg := range []string{"sha-512", "SHA-256", ""} {
+		claims := AnswersClaims{
+			StandardClaims: jwt.StandardClaims{Subject: "JonnyBoy"},
+			SdAlg:          alg,
+			Answers:        []DisclosureDigest{{Digest: disclosureDigest(signed.Disclosures[0])}},
+		}
+		signature, err := signClaims(claims, key, TypeAnswers)
+		require.NoError(t, err)
+		_, err = s.VerifyPresentation(present(signature, signed.Disclosures[0]))
+		assert.Error(t, err, alg)
+	}
+
+	// a signature over the answers digest has nothing to disclose
+	digest, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, TokenFormatJwt)
+	require.NoError(t, err)
+	_, err = s.VerifyPresentation(present(digest.Signature))
+	assert.EqualError(t, err, "signature has no disclosable answers")
+}
+
+func TestVerifyPresentationRejectsKeyBinding(t *testing.T) {
+	s := newTestSigner(t, nil)
+	signed := signSdJwt(t, s)
+
+	// a KB-JWT, valid or not, is never accepted in place of the trailing separator
+	key, err := s.keys.SigningKey()
+	require.NoError(t, err)
+	kb := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"nonce": "1234", "aud": "verifier", "sd_hash": "hash"})
+	kb.Header["typ"] = "kb+jwt"
+	kbJwt, err := kb.SignedString(key.Private)
+	require.NoError(t, err)
+	for name, presentation := range map[string]string{
+		"kb-jwt":     signed.Token() + kbJwt,
+		"garbage":    signed.Token() + "kb",
+		"no trailer": strings.TrimSuffix(signed.Token(), disclosureSeparator),
+	} {
+		_, err = s.VerifyPresentation(presentation)
+		assert.EqualError(t, err, "key binding is not supported", name)
+	}
+}
+
+func TestVerifyPresentationRejectsOtherSignatures(t *testing.T) {
+	s := newTestSigner(t, nil, "EdDSA")
+	signed := signSdJwt(t, s)
+
+	_, err := s.VerifyPresentation(signed.Signature)
+	assert.EqualError(t, err, "presentation is not an SD-JWT")
+
+	public, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, "v4.public")
+	require.NoError(t, err)
+	_, err = s.VerifyPresentation(present(public.Signature))
+	assert.EqualError(t, err, "presentation is not an SD-JWT")
+}
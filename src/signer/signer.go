@@ -0,0 +1,332 @@
+package signer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"jwt-sign/configuration"
+	"jwt-sign/keystore"
+	"jwt-sign/paseto"
+)
+
+// Values of the typ header of the compact JWS produced by the signer, so that a token can never pass for an answers
+// signature or the other way round.
+const (
+	// TypeAccessToken typ of the tokens minted by Issue, as in RFC 9068
+	TypeAccessToken = "at+jwt"
+	// TypeAnswers typ of the answers signatures
+	TypeAnswers = "answers+jwt"
+)
+
+// AnswersClaims are the claims of the compact JWS returned for a set of signed answers.
+type AnswersClaims struct {
+	jwt.StandardClaims
+	AnswersDigest    string `json:"answers_digest,omitempty"`
+	AnswersDigestAlg string `json:"answers_digest_alg,omitempty"`
+	AnswersFormat    string `json:"answers_format,omitempty"`
+	// SdAlg and Answers replace the answers digest in SD-JWT, Answers lists the digests of the disclosable answers
+	SdAlg   string             `json:"_sd_alg,omitempty"`
+	Answers []DisclosureDigest `json:"answers,omitempty"`
+	// AnswersRoot replaces the answers digest in Merkle signatures, the root of the tree over AnswersCount salted answers
+	AnswersRoot    string `json:"answers_root,omitempty"`
+	AnswersRootAlg string `json:"answers_root_alg,omitempty"`
+	AnswersCount   int    `json:"answers_count,omitempty"`
+}
+
+// validate checks that the claims hold answers: a digest, the digests of disclosable answers or a Merkle root.
+func (c *AnswersClaims) validate() error {
+	switch {
+	case c.AnswersDigest != "" && c.AnswersDigestAlg == DigestAlgorithm:
+	case c.SdAlg == DigestAlgorithm && len(c.Answers) > 0:
+	case c.AnswersRoot != "" && c.AnswersRootAlg == DigestAlgorithm && c.AnswersCount > 0:
+	default:
+		return fmt.Errorf("signature does not hold signed answers")
+	}
+	if c.Subject == "" {
+		return fmt.Errorf("signature has no subject")
+	}
+	return nil
+}
+
+// Signer produces compact JWS over the canonical encoding of question/answer pairs and issues tokens.
+type Signer struct {
+	keys   keystore.KeyStore
+	issuer string
+	// tokenKeyId is the kid of the key signing issued tokens, the answers signing key signs them when empty
+	tokenKeyId string
+	policy     IssuancePolicy
+	formats    FormatPolicy
+	// pasetoKey encrypts v4.local tokens, nil when no key is configured
+	pasetoKey []byte
+	// answersPasetoKey encrypts and decrypts v4.local answers signatures, nil when no key is configured
+	answersPasetoKey []byte
+}
+
+var signer *Signer
+
+// Init builds the package signer from the application configuration and the key store. It must be called once
+// at startup, before any handler calls Sign or Verify.
+func Init(conf *configuration.Configuration, keys keystore.KeyStore) error {
+	s, err := NewSigner(conf, keys)
+	if err != nil {
+		return err
+	}
+	signer = s
+	return nil
+}
+
+// SignedAnswers is the result of signing the answers of a subject.
+type SignedAnswers struct {
+	Signature string
+	Claims    AnswersClaims
+	KeyId     string
+	Algorithm string
+	// Disclosures of the answers of an SD-JWT, nil for other formats
+	Disclosures []string
+	// Proofs of inclusion of the answers in the signed Merkle root, nil for other formats
+	Proofs []InclusionProof
+}
+
+// Sign signs the answers using the package signer initialized by Init.
+func Sign(subject string, questions, answers []string, format string) (*SignedAnswers, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("answers signer is not initialized")
+	}
+	return signer.Sign(subject, questions, answers, format)
+}
+
+// NewSigner creates a Signer using the signing key of the key store.
+//
+// Parameters:
+//   - conf *configuration.Configuration: application configuration holding the signing settings
+//   - keys keystore.KeyStore: key store providing the signing and verification keys
+//
+// Returns:
+//   - *Signer: The signer
+//   - error: An error if the key store has no usable signing key, or the paseto settings are invalid
+func NewSigner(conf *configuration.Configuration, keys keystore.KeyStore) (*Signer, error) {
+	s := &Signer{keys: keys, issuer: conf.SigningIssuer, tokenKeyId: conf.TokenSigningKeyId, policy: NewIssuancePolicy(conf)}
+	var err error
+	if s.formats, err = NewFormatPolicy(conf); err != nil {
+		return nil, err
+	}
+	if s.pasetoKey, err = paseto.ParseKey(conf.PasetoLocalKey); err != nil {
+		return nil, err
+	}
+	if s.answersPasetoKey, err = paseto.ParseKey(conf.SignaturePasetoLocalKey); err != nil {
+		return nil, err
+	}
+	// a key decrypting incoming tokens must never decrypt answers signatures, or the other way round
+	if s.answersPasetoKey != nil && bytes.Equal(s.answersPasetoKey, s.pasetoKey) {
+		return nil, fmt.Errorf("answers signatures and tokens must not share the paseto local key")
+	}
+	key, err := keys.SigningKey()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = signingMethod(key); err != nil {
+		return nil, err
+	}
+	if s.tokenKeyId != "" {
+		if key.Id == s.tokenKeyId {
+			return nil, fmt.Errorf("token signing key %q must not sign answers", s.tokenKeyId)
+		}
+		if key, err = s.tokenSigningKey(); err != nil {
+			return nil, err
+		}
+		if _, err = signingMethod(key); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Sign produces a compact JWS, or a PASETO, binding the digest of the question/answer pairs to the subject, or an
+// SD-JWT or a Merkle root signature where every pair can be disclosed on its own.
+//
+// Parameters:
+//   - subject string: Subject of the presented JWT the answers belong to
+//   - questions []string: List of questions for which answers are provided
+//   - answers []string: List of answers corresponding to the questions
+//   - format string: One of SignatureFormats, a compact JWS when empty
+//
+// Returns:
+//   - *SignedAnswers: The signature along with its claims and the key id and algorithm that produced it
+//   - error: An error, if any, encountered during the signing process
+func (s *Signer) Sign(subject string, questions, answers []string, format string) (*SignedAnswers, error) {
+	if format == TokenFormatSdJwt || format == TokenFormatMerkle {
+		pairs, err := answerPairs(questions, answers)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]interface{}, len(pairs))
+		for i := range pairs {
+			items[i] = pairs[i]
+		}
+		return s.signItems(subject, items, "", format)
+	}
+	digest, err := Digest(questions, answers)
+	if err != nil {
+		return nil, err
+	}
+	return s.signDigest(subject, digest, "", format)
+}
+
+// signItems signs the answers in their canonical form so that each of them can be disclosed on its own, as an SD-JWT
+// or as a Merkle root.
+func (s *Signer) signItems(subject string, items []interface{}, answersFormat, format string) (*SignedAnswers, error) {
+	if format == TokenFormatMerkle {
+		return s.signMerkle(subject, items, answersFormat)
+	}
+	return s.signDisclosures(subject, items, answersFormat)
+}
+
+// signDigest signs the answers digest bound to the subject in the token format, answersFormat tells how the answers
+// were canonicalized.
+func (s *Signer) signDigest(subject, digest, answersFormat, format string) (*SignedAnswers, error) {
+	claims := AnswersClaims{AnswersDigest: digest, AnswersDigestAlg: DigestAlgorithm, AnswersFormat: answersFormat}
+	return s.signAnswers(subject, claims, format)
+}
+
+// signAnswers sets the registered claims of the answers claims and signs them in the token format.
+func (s *Signer) signAnswers(subject string, claims AnswersClaims, format string) (*SignedAnswers, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("cannot sign answers without a subject")
+	}
+	claims.StandardClaims = jwt.StandardClaims{
+		Id:       uuid.New().String(),
+		IssuedAt: time.Now().Unix(),
+		Issuer:   s.issuer,
+		Subject:  subject,
+	}
+	if format != "" && format != TokenFormatJwt {
+		signature, kid, err := s.signPaseto(claims, format, TypeAnswers)
+		if err != nil {
+			return nil, err
+		}
+		return &SignedAnswers{Signature: signature, Claims: claims, KeyId: kid, Algorithm: format}, nil
+	}
+	key, err := s.keys.SigningKey()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signClaims(claims, key, TypeAnswers)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedAnswers{Signature: signature, Claims: claims, KeyId: key.Id, Algorithm: key.Algorithm}, nil
+}
+
+// signClaims signs the claims with the key, typ tells a token from an answers signature.
+func signClaims(claims jwt.Claims, key *keystore.Key, typ string) (string, error) {
+	method, err := signingMethod(key)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.Id
+	token.Header["typ"] = typ
+	return token.SignedString(key.Private)
+}
+
+// tokenSigningKey returns the key signing issued tokens: the configured token key, or else the answers signing key.
+func (s *Signer) tokenSigningKey() (*keystore.Key, error) {
+	if s.tokenKeyId == "" {
+		return s.keys.SigningKey()
+	}
+	key, err := s.keys.VerificationKey(s.tokenKeyId)
+	if err != nil {
+		return nil, err
+	}
+	if !key.CanSign() {
+		return nil, fmt.Errorf("%w: no private key with kid %q", keystore.ErrNoSigningKey, s.tokenKeyId)
+	}
+	return key, nil
+}
+
+// answersKey returns the key of the key store that verifies answers signatures under kid, never the token key.
+func (s *Signer) answersKey(kid string) (*keystore.Key, error) {
+	if s.tokenKeyId != "" && kid == s.tokenKeyId {
+		return nil, fmt.Errorf("key %q signs tokens, not answers", kid)
+	}
+	key, err := s.keys.VerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.Use != keystore.UseSignature {
+		return nil, fmt.Errorf("key %q is not a signature key", kid)
+	}
+	return key, nil
+}
+
+// Verification is the result of a successful signature verification.
+type Verification struct {
+	Claims    AnswersClaims
+	KeyId     string
+	Algorithm string
+}
+
+// Verify verifies a signature using the package signer initialized by Init.
+func Verify(signature string) (*Verification, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("answers signer is not initialized")
+	}
+	return signer.Verify(signature)
+}
+
+// Verify checks that the compact JWS, or the PASETO, was produced by a key of the key store and holds signed answers.
+// A compact JWS must be typed as an answers signature, tokens issued by the signer are rejected.
+//
+// Parameters:
+//   - signature string: The compact JWS or the PASETO returned by Sign
+//
+// Returns:
+//   - *Verification: The verified claims along with the key id and algorithm that verified them
+//   - error: An error if the signature is malformed, was produced by an unknown key, does not verify or does not hold
+//     answers
+func (s *Signer) Verify(signature string) (*Verification, error) {
+	if paseto.IsToken(signature) {
+		return s.verifyPaseto(signature)
+	}
+	var (
+		claims AnswersClaims
+		key    *keystore.Key
+	)
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(signature, &claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); !strings.EqualFold(typ, TypeAnswers) {
+			return nil, fmt.Errorf("signature is typed %q instead of %s", typ, TypeAnswers)
+		}
+		kid, _ := token.Header["kid"].(string)
+		k, err := s.answersKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// the algorithm is bound to the key, never taken from the token header
+		if token.Method.Alg() != k.Algorithm {
+			return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+		}
+		key = k
+		return k.Public, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = claims.validate(); err != nil {
+		return nil, err
+	}
+	return &Verification{Claims: claims, KeyId: key.Id, Algorithm: token.Method.Alg()}, nil
+}
+
+// signingMethod returns the JWS signing method for the algorithm of the key.
+func signingMethod(key *keystore.Key) (jwt.SigningMethod, error) {
+	method := jwt.GetSigningMethod(key.Algorithm)
+	if method == nil || method == jwt.SigningMethodNone {
+		return nil, fmt.Errorf("unsupported signing algorithm %q for key %q", key.Algorithm, key.Id)
+	}
+	return method, nil
+}
@@ -0,0 +1,169 @@
+package signer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/configuration"
+	"jwt-sign/keystore"
+	"jwt-sign/paseto"
+)
+
+// testConfiguration returns the configuration of the signers under test.
+func testConfiguration() *configuration.Configuration {
+	return &configuration.Configuration{
+		SigningIssuer:           "jwt-sign",
+		TokenDefaultTtlSec:      900,
+		TokenMaxTtlSec:          3600,
+		PasetoLocalKey:          "707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f",
+		SignaturePasetoLocalKey: "909192939495969798999a9b9c9d9e9fa0a1a2a3a4a5a6a7a8a9aaabacadaeaf",
+	}
+}
+
+// newTestSigner creates a signer over a key store holding a key for every algorithm, the first one signing.
+func newTestSigner(t *testing.T, configure func(conf *configuration.Configuration), algorithms ...string) *Signer {
+	t.Helper()
+	if len(algorithms) == 0 {
+		algorithms = []string{"ES256"}
+	}
+	keys := make([]*keystore.Key, len(algorithms))
+	for i, alg := range algorithms {
+		key, err := keystore.Generate(alg, "k"+alg)
+		require.NoError(t, err)
+		keys[i] = key
+	}
+	conf := testConfiguration()
+	if configure != nil {
+		configure(conf)
+	}
+	s, err := NewSigner(conf, keystore.NewMemoryKeyStore(keys[0].Id, keys...))
+	require.NoError(t, err)
+	return s
+}
+
+func TestSignAndVerify(t *testing.T) {
+	s := newTestSigner(t, nil, "ES256", "EdDSA")
+	for _, format := range []string{"", TokenFormatJwt, paseto.PurposePublic, paseto.PurposeLocal} {
+		signed, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, format)
+		require.NoError(t, err, format)
+
+		verification, err := s.Verify(signed.Signature)
+		require.NoError(t, err, format)
+		assert.Equal(t, "JonnyBoy", verification.Claims.Subject)
+		assert.Equal(t, signed.Claims.AnswersDigest, verification.Claims.AnswersDigest)
+		assert.Equal(t, signed.KeyId, verification.KeyId)
+	}
+
+	signed, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, "")
+	require.NoError(t, err)
+	header, _, err := new(jwt.Parser).ParseUnverified(signed.Signature, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, TypeAnswers, header.Header["typ"])
+}
+
+func TestVerifyRejectsIssuedTokens(t *testing.T) {
+	s := newTestSigner(t, nil, "ES256", "EdDSA")
+	for _, format := range []string{TokenFormatJwt, paseto.PurposePublic, paseto.PurposeLocal} {
+		issued, err := s.Issue("JonnyBoy", nil, nil, time.Minute, format)
+		require.NoError(t, err, format)
+		_, err = s.Verify(issued.Token)
+		assert.Error(t, err, format)
+	}
+
+	issued, err := s.Issue("JonnyBoy", nil, nil, time.Minute, "")
+	require.NoError(t, err)
+	header, _, err := new(jwt.Parser).ParseUnverified(issued.Token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, TypeAccessToken, header.Header["typ"])
+}
+
+func TestVerifyRequiresAnswersTypeAndClaims(t *testing.T) {
+	s := newTestSigner(t, nil)
+	key, err := s.keys.SigningKey()
+	require.NoError(t, err)
+	claims := AnswersClaims{
+		StandardClaims:   jwt.StandardClaims{Subject: "JonnyBoy", Issuer: "jwt-sign"},
+		AnswersDigest:    "digest",
+		AnswersDigestAlg: DigestAlgorithm,
+	}
+
+	for name, typ := range map[string]string{"untyped": "", "access token": TypeAccessToken, "jwt": "JWT"} {
+		signature, err := signClaims(claims, key, typ)
+		require.NoError(t, err)
+		_, err = s.Verify(signature)
+		assert.Error(t, err, name)
+	}
+
+	for name, invalid := range map[string]AnswersClaims{
+		"no answers":     {StandardClaims: claims.StandardClaims},
+		"no digest alg":  {StandardClaims: claims.StandardClaims, AnswersDigest: "digest"},
+		"no subject":     {AnswersDigest: "digest", AnswersDigestAlg: DigestAlgorithm},
+		"no disclosures": {StandardClaims: claims.StandardClaims, SdAlg: DigestAlgorithm},
+		"empty tree":     {StandardClaims: claims.StandardClaims, AnswersRoot: "root", AnswersRootAlg: DigestAlgorithm},
+	} {
+		signature, err := signClaims(invalid, key, TypeAnswers)
+		require.NoError(t, err)
+		_, err = s.Verify(signature)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestIssueRejectsReservedClaims(t *testing.T) {
+	s := newTestSigner(t, nil)
+	for _, name := range []string{"sub", "exp", "answers_digest", "_sd_alg", "answers_root"} {
+		_, err := s.Issue("JonnyBoy", nil, map[string]interface{}{name: "value"}, time.Minute, "")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestTokenSigningKey(t *testing.T) {
+	s := newTestSigner(t, func(conf *configuration.Configuration) {
+		conf.TokenSigningKeyId = "kES384"
+	}, "ES256", "ES384")
+
+	issued, err := s.Issue("JonnyBoy", nil, nil, time.Minute, "")
+	require.NoError(t, err)
+	assert.Equal(t, "kES384", issued.KeyId)
+
+	signed, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "kES256", signed.KeyId)
+
+	// answers signed by the token key are not accepted, whatever their typ
+	tokenKey, err := s.tokenSigningKey()
+	require.NoError(t, err)
+	forged, err := signClaims(signed.Claims, tokenKey, TypeAnswers)
+	require.NoError(t, err)
+	_, err = s.Verify(forged)
+	assert.Error(t, err)
+
+	// the token key cannot be the answers signing key
+	keys := s.keys.(*keystore.MemoryKeyStore)
+	conf := testConfiguration()
+	conf.TokenSigningKeyId = "kES256"
+	_, err = NewSigner(conf, keys)
+	assert.Error(t, err)
+}
+
+func TestPasetoSignaturesAreBoundToAnswers(t *testing.T) {
+	s := newTestSigner(t, nil, "ES256", "EdDSA")
+	signed, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, "")
+	require.NoError(t, err)
+
+	// the same answers claims, signed or encrypted as a token, are not an answers signature
+	for _, format := range []string{paseto.PurposePublic, paseto.PurposeLocal} {
+		token, _, err := s.signPaseto(signed.Claims, format, TypeAccessToken)
+		require.NoError(t, err, format)
+		_, err = s.Verify(token)
+		assert.Error(t, err, format)
+	}
+
+	// the tokens key cannot encrypt answers signatures
+	conf := testConfiguration()
+	conf.SignaturePasetoLocalKey = conf.PasetoLocalKey
+	_, err = NewSigner(conf, s.keys)
+	assert.Error(t, err)
+}
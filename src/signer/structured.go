@@ -0,0 +1,145 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// FormatStructured value of the answers_format claim of signatures over structured answers. Signatures over
+// question/answer pairs have no answers_format claim.
+const FormatStructured = "structured"
+
+// Answer is the typed answer to one question: a string, a float64 number, a bool or a []string of choices.
+type Answer struct {
+	QuestionId string
+	Value      interface{}
+}
+
+// structuredAnswer is the canonical form of a typed answer.
+type structuredAnswer struct {
+	QuestionId string      `json:"id"`
+	Answer     interface{} `json:"a"`
+}
+
+// CanonicalizeStructured encodes the typed answers in their canonical form: a JSON array of {"id","a"} objects sorted
+// by question id, where "a" keeps the JSON type of the answer and numbers are in their shortest form. Strings are
+// escaped as in Canonicalize.
+//
+// Parameters:
+//   - answers []Answer: The typed answers
+//
+// Returns:
+//   - []byte: The canonical encoding
+//   - error: An error if a question is answered twice or an answer has an unsupported type
+func CanonicalizeStructured(answers []Answer) ([]byte, error) {
+	items, err := structuredAnswers(answers)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`{"id":`)
+		writeCanonicalString(&b, item.QuestionId)
+		b.WriteString(`,"a":`)
+		switch value := item.Answer.(type) {
+		case string:
+			writeCanonicalString(&b, value)
+		case []string:
+			b.WriteByte('[')
+			for j, choice := range value {
+				if j > 0 {
+					b.WriteByte(',')
+				}
+				writeCanonicalString(&b, choice)
+			}
+			b.WriteByte(']')
+		default:
+			// numbers and booleans hold no string, encoding/json writes numbers in their shortest form
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("answer to %s cannot be encoded: %s", item.QuestionId, err.Error())
+			}
+			b.Write(encoded)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(']')
+	return b.Bytes(), nil
+}
+
+// structuredAnswers returns the typed answers in their canonical form, sorted by question id.
+func structuredAnswers(answers []Answer) ([]structuredAnswer, error) {
+	items := make([]structuredAnswer, len(answers))
+	for i, answer := range answers {
+		switch answer.Value.(type) {
+		case string, float64, bool, []string:
+		default:
+			return nil, fmt.Errorf("answer to %s has unsupported type %T", answer.QuestionId, answer.Value)
+		}
+		items[i] = structuredAnswer{QuestionId: answer.QuestionId, Answer: answer.Value}
+	}
+	// the order of the answers in the request carries no meaning
+	sort.Slice(items, func(i, j int) bool { return items[i].QuestionId < items[j].QuestionId })
+	for i := 1; i < len(items); i++ {
+		if items[i].QuestionId == items[i-1].QuestionId {
+			return nil, fmt.Errorf("question %s is answered more than once", items[i].QuestionId)
+		}
+	}
+	return items, nil
+}
+
+// DigestStructured returns the base64url encoded SHA-256 of the canonical encoding of the typed answers.
+func DigestStructured(answers []Answer) (string, error) {
+	canonical, err := CanonicalizeStructured(answers)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// SignStructured signs the typed answers using the package signer initialized by Init.
+func SignStructured(subject string, answers []Answer, format string) (*SignedAnswers, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("answers signer is not initialized")
+	}
+	return signer.SignStructured(subject, answers, format)
+}
+
+// SignStructured produces a compact JWS binding the digest of the typed answers to the subject, or an SD-JWT or a
+// Merkle root signature where every typed answer can be disclosed on its own.
+//
+// Parameters:
+//   - subject string: Subject of the presented JWT the answers belong to
+//   - answers []Answer: The typed answers
+//   - format string: One of SignatureFormats, a compact JWS when empty
+//
+// Returns:
+//   - *SignedAnswers: The signature along with its claims and the key id and algorithm that produced it
+//   - error: An error, if any, encountered during the signing process
+func (s *Signer) SignStructured(subject string, answers []Answer, format string) (*SignedAnswers, error) {
+	if format == TokenFormatSdJwt || format == TokenFormatMerkle {
+		items, err := structuredAnswers(answers)
+		if err != nil {
+			return nil, err
+		}
+		disclosed := make([]interface{}, len(items))
+		for i := range items {
+			disclosed[i] = items[i]
+		}
+		return s.signItems(subject, disclosed, FormatStructured, format)
+	}
+	digest, err := DigestStructured(answers)
+	if err != nil {
+		return nil, err
+	}
+	return s.signDigest(subject, digest, FormatStructured, format)
+}
@@ -0,0 +1,89 @@
+package signer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/paseto"
+)
+
+// testStructuredAnswers answers with every supported type, out of order.
+func testStructuredAnswers() []Answer {
+	return []Answer{
+		{QuestionId: "name", Value: "JonnyBoy"},
+		{QuestionId: "age", Value: 42.0},
+		{QuestionId: "consent", Value: true},
+		{QuestionId: "languages", Value: []string{"de", "ro"}},
+		{QuestionId: "height", Value: 1.85},
+	}
+}
+
+func TestCanonicalizeStructured(t *testing.T) {
+	canonical, err := CanonicalizeStructured(testStructuredAnswers())
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id":"age","a":42},{"id":"consent","a":true},{"id":"height","a":1.85},{"id":"languages","a":["de","ro"]},{"id":"name","a":"JonnyBoy"}]`,
+		string(canonical))
+
+	// an answer keeps its type, so a number and its text differ
+	text, err := CanonicalizeStructured([]Answer{{QuestionId: "age", Value: "42"}})
+	require.NoError(t, err)
+	number, err := CanonicalizeStructured([]Answer{{QuestionId: "age", Value: 42.0}})
+	require.NoError(t, err)
+	assert.NotEqual(t, text, number)
+
+	// strings are escaped as in the canonical form of question/answer pairs, html characters are kept
+	escaped, err := CanonicalizeStructured([]Answer{{QuestionId: "show<&>", Value: []string{"<b>Tom & Jerry</b>", "a\"b"}}})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id":"show<&>","a":["<b>Tom & Jerry</b>","a\"b"]}]`, string(escaped))
+
+	_, err = CanonicalizeStructured([]Answer{{QuestionId: "age", Value: 42.0}, {QuestionId: "age", Value: 43.0}})
+	assert.EqualError(t, err, "question age is answered more than once")
+	for _, value := range []interface{}{nil, 42, []interface{}{"de"}, map[string]string{"a": "b"}} {
+		_, err = CanonicalizeStructured([]Answer{{QuestionId: "q", Value: value}})
+		assert.Error(t, err, "%T", value)
+	}
+}
+
+func TestDigestStructured(t *testing.T) {
+	// SHA-256 of the canonical encoding above, computed independently
+	digest, err := DigestStructured(testStructuredAnswers())
+	require.NoError(t, err)
+	assert.Equal(t, "QvpsiRtLmD3HY7rO1M8st9SX4uddUj1VeenQu3-DWBo", digest)
+
+	// the order of the answers is not part of what is signed
+	answers := testStructuredAnswers()
+	answers[0], answers[4] = answers[4], answers[0]
+	reordered, err := DigestStructured(answers)
+	require.NoError(t, err)
+	assert.Equal(t, digest, reordered)
+}
+
+func TestSignStructured(t *testing.T) {
+	s := newTestSigner(t, nil, "ES256", "EdDSA")
+	digest, err := DigestStructured(testStructuredAnswers())
+	require.NoError(t, err)
+
+	for _, format := range []string{"", TokenFormatJwt, paseto.PurposePublic, paseto.PurposeLocal} {
+		signed, err := s.SignStructured("JonnyBoy", testStructuredAnswers(), format)
+		require.NoError(t, err, format)
+		verification, err := s.Verify(signed.Signature)
+		require.NoError(t, err, format)
+		assert.Equal(t, "JonnyBoy", verification.Claims.Subject, format)
+		assert.Equal(t, digest, verification.Claims.AnswersDigest, format)
+		assert.Equal(t, FormatStructured, verification.Claims.AnswersFormat, format)
+	}
+
+	// every answer can be disclosed on its own, with its type
+	signed, err := s.SignStructured("JonnyBoy", testStructuredAnswers(), TokenFormatMerkle)
+	require.NoError(t, err)
+	verification, err := s.Verify(signed.Signature)
+	require.NoError(t, err)
+	assert.Equal(t, FormatStructured, verification.Claims.AnswersFormat)
+	answer, err := VerifyInclusion(verification.Claims, signed.Proofs[0])
+	require.NoError(t, err)
+	assert.Equal(t, &DisclosedAnswer{QuestionId: "age", Value: 42.0}, answer)
+
+	_, err = s.SignStructured("JonnyBoy", []Answer{{QuestionId: "q", Value: 42}}, TokenFormatSdJwt)
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// onboardingBucket bucket holding correlation id -> json encoded record
+var onboardingBucket = []byte("onboarding")
+
+// BoltRepository is an on-disk Repository backed by an embedded bbolt database.
+type BoltRepository struct {
+	db *bolt.DB
+}
+
+// NewBoltRepository opens, or creates, the database file.
+//
+// Parameters:
+//   - path string: Path of the database file
+//
+// Returns:
+//   - *BoltRepository: The repository
+//   - error: An error if the database cannot be opened
+func NewBoltRepository(path string) (*BoltRepository, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening onboarding store %s: %s", path, err.Error())
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(onboardingBucket)
+		return err
+	})
+	if err != nil {
+		//goland:noinspection GoUnhandledErrorResult
+		db.Close()
+		return nil, fmt.Errorf("error initializing onboarding store %s: %s", path, err.Error())
+	}
+	return &BoltRepository{db: db}, nil
+}
+
+// Save creates or replaces the record.
+func (b *BoltRepository) Save(r *Record) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(onboardingBucket)
+		var previous *Record
+		if v := bucket.Get([]byte(r.CorrelationId)); v != nil {
+			previous = &Record{}
+			if err := json.Unmarshal(v, previous); err != nil {
+				return fmt.Errorf("error decoding onboarding record %s: %s", r.CorrelationId, err.Error())
+			}
+		}
+		touch(r, previous, time.Now().UTC())
+		v, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("error encoding onboarding record %s: %s", r.CorrelationId, err.Error())
+		}
+		return bucket.Put([]byte(r.CorrelationId), v)
+	})
+}
+
+// Get returns the record created by the request with the correlation id.
+func (b *BoltRepository) Get(correlationId string) (*Record, error) {
+	var r *Record
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(onboardingBucket).Get([]byte(correlationId))
+		if v == nil {
+			return ErrNotFound
+		}
+		r = &Record{}
+		if err := json.Unmarshal(v, r); err != nil {
+			return fmt.Errorf("error decoding onboarding record %s: %s", correlationId, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// FindByTokenId returns the records created with the jwt carrying the jti, oldest first.
+func (b *BoltRepository) FindByTokenId(jti string) ([]*Record, error) {
+	var found []*Record
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(onboardingBucket).ForEach(func(k, v []byte) error {
+			r := &Record{}
+			if err := json.Unmarshal(v, r); err != nil {
+				return fmt.Errorf("error decoding onboarding record %s: %s", k, err.Error())
+			}
+			if r.TokenId == jti {
+				found = append(found, r)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].CreatedAt.Before(found[j].CreatedAt) })
+	return found, nil
+}
+
+// Close closes the database.
+func (b *BoltRepository) Close() error {
+	return b.db.Close()
+}
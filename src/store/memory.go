@@ -0,0 +1,70 @@
+package store
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository is an in-memory Repository, its content is lost on restart.
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+// NewMemoryRepository creates an empty in-memory repository.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{records: map[string]Record{}}
+}
+
+// Save creates or replaces the record.
+func (m *MemoryRepository) Save(r *Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var previous *Record
+	if p, ok := m.records[r.CorrelationId]; ok {
+		previous = &p
+	}
+	touch(r, previous, time.Now().UTC())
+	m.records[r.CorrelationId] = copyRecord(*r)
+	return nil
+}
+
+// Get returns the record created by the request with the correlation id.
+func (m *MemoryRepository) Get(correlationId string) (*Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.records[correlationId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	r = copyRecord(r)
+	return &r, nil
+}
+
+// FindByTokenId returns the records created with the jwt carrying the jti, oldest first.
+func (m *MemoryRepository) FindByTokenId(jti string) ([]*Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var found []*Record
+	for _, r := range m.records {
+		if r.TokenId == jti {
+			c := copyRecord(r)
+			found = append(found, &c)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].CreatedAt.Before(found[j].CreatedAt) })
+	return found, nil
+}
+
+// Close is a no-op.
+func (m *MemoryRepository) Close() error {
+	return nil
+}
+
+// copyRecord detaches the slices of r so callers cannot modify the stored record.
+func copyRecord(r Record) Record {
+	r.Questions = append([]string(nil), r.Questions...)
+	r.AnswerHashes = append([]string(nil), r.AnswerHashes...)
+	return r
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"jwt-sign/configuration"
+)
+
+// Status is the state of an onboarding record.
+type Status string
+
+const (
+	// StatusReceived the jwt was verified and the answers were received
+	StatusReceived Status = "received"
+	// StatusSigned the answers were signed
+	StatusSigned Status = "signed"
+	// StatusFailed the answers could not be signed
+	StatusFailed Status = "failed"
+	// StatusCompleted the onboarding process finished
+	StatusCompleted Status = "completed"
+)
+
+// ErrNotFound is returned when no record exists for the requested id.
+var ErrNotFound = errors.New("onboarding record not found")
+
+// Record is one onboarding validation, identified by the correlation id of the request that created it.
+type Record struct {
+	CorrelationId  string    `json:"correlationId"`
+	Subject        string    `json:"subject"`
+	TokenId        string    `json:"tokenId,omitempty"`
+	Token          string    `json:"token"`
+	Questionnaire  string    `json:"questionnaire,omitempty"`
+	Questions      []string  `json:"questions"`
+	AnswersSalt    string    `json:"answersSalt,omitempty"`
+	AnswersHashAlg string    `json:"answersHashAlg,omitempty"`
+	AnswerHashes   []string  `json:"answerHashes"`
+	SignatureHash  string    `json:"signatureHash,omitempty"`
+	Status         Status    `json:"status"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Repository persists onboarding records.
+type Repository interface {
+	// Save creates or replaces the record, CreatedAt is kept from the stored record and UpdatedAt is refreshed.
+	Save(r *Record) error
+	// Get returns the record created by the request with the correlation id, ErrNotFound if there is none.
+	Get(correlationId string) (*Record, error)
+	// FindByTokenId returns the records created with the jwt carrying the jti, oldest first.
+	FindByTokenId(jti string) ([]*Record, error)
+	// Close releases the resources held by the repository.
+	Close() error
+}
+
+var repository Repository
+
+// Init builds the package repository from the application configuration: an on-disk repository when a file is
+// configured, an in-memory repository otherwise.
+func Init(conf *configuration.Configuration) error {
+	log := logger.SugaredLogger().With("package", "store", "action", "Init")
+	if conf.OnboardingStoreFile != "" {
+		r, err := NewBoltRepository(conf.OnboardingStoreFile)
+		if err != nil {
+			return err
+		}
+		log.Infof("onboarding store opened at %s", conf.OnboardingStoreFile)
+		repository = r
+	} else {
+		log.Warnf("no onboarding store file configured, onboarding records will be lost after a restart!")
+		repository = NewMemoryRepository()
+	}
+	return nil
+}
+
+// Default returns the package repository initialized by Init.
+func Default() Repository {
+	return repository
+}
+
+// touch sets the timestamps of the record about to be saved over previous, which may be nil.
+func touch(r *Record, previous *Record, now time.Time) {
+	if previous != nil {
+		r.CreatedAt = previous.CreatedAt
+	} else if r.CreatedAt.IsZero() {
+		r.CreatedAt = now
+	}
+	r.UpdatedAt = now
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// repositories returns an instance of every repository implementation, closed at the end of the test.
+func repositories(t *testing.T) map[string]Repository {
+	t.Helper()
+	bolt, err := NewBoltRepository(filepath.Join(t.TempDir(), "onboarding.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bolt.Close() })
+	return map[string]Repository{
+		"memory": NewMemoryRepository(),
+		"bolt":   bolt,
+	}
+}
+
+func TestRepositorySaveAndGet(t *testing.T) {
+	for name, repo := range repositories(t) {
+		_, err := repo.Get("c1")
+		assert.True(t, errors.Is(err, ErrNotFound), name)
+
+		r := &Record{
+			CorrelationId: "c1",
+			Subject:       "JonnyBoy",
+			TokenId:       "jti1",
+			Questions:     []string{"question1"},
+			AnswerHashes:  []string{"hash1"},
+			Status:        StatusReceived,
+		}
+		require.NoError(t, repo.Save(r), name)
+		assert.False(t, r.CreatedAt.IsZero(), name)
+		assert.Equal(t, r.CreatedAt, r.UpdatedAt, name)
+
+		stored, err := repo.Get("c1")
+		require.NoError(t, err, name)
+		assert.Equal(t, r, stored, name)
+
+		// an update keeps the creation time
+		createdAt := r.CreatedAt
+		update := *stored
+		update.Status = StatusSigned
+		update.SignatureHash = "signatureHash"
+		update.CreatedAt = time.Time{}
+		time.Sleep(time.Millisecond)
+		require.NoError(t, repo.Save(&update), name)
+		stored, err = repo.Get("c1")
+		require.NoError(t, err, name)
+		assert.Equal(t, StatusSigned, stored.Status, name)
+		assert.Equal(t, "signatureHash", stored.SignatureHash, name)
+		assert.True(t, createdAt.Equal(stored.CreatedAt), name)
+		assert.True(t, stored.UpdatedAt.After(createdAt), name)
+	}
+}
+
+func TestRepositoryFindByTokenId(t *testing.T) {
+	now := time.Now().UTC()
+	for name, repo := range repositories(t) {
+		for _, r := range []*Record{
+			{CorrelationId: "c3", TokenId: "jti1", CreatedAt: now.Add(2 * time.Second)},
+			{CorrelationId: "c1", TokenId: "jti1", CreatedAt: now},
+			{CorrelationId: "c2", TokenId: "jti2", CreatedAt: now.Add(time.Second)},
+		} {
+			require.NoError(t, repo.Save(r), name)
+		}
+
+		found, err := repo.FindByTokenId("jti1")
+		require.NoError(t, err, name)
+		require.Len(t, found, 2, name)
+		assert.Equal(t, "c1", found[0].CorrelationId, name)
+		assert.Equal(t, "c3", found[1].CorrelationId, name)
+
+		found, err = repo.FindByTokenId("unknown")
+		require.NoError(t, err, name)
+		assert.Empty(t, found, name)
+	}
+}
+
+func TestMemoryRepositoryDetachesRecords(t *testing.T) {
+	repo := NewMemoryRepository()
+	r := &Record{CorrelationId: "c1", Questions: []string{"question1"}, AnswerHashes: []string{"hash1"}}
+	require.NoError(t, repo.Save(r))
+	r.Questions[0] = "changed"
+
+	stored, err := repo.Get("c1")
+	require.NoError(t, err)
+	stored.AnswerHashes[0] = "changed"
+	stored, err = repo.Get("c1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"question1"}, stored.Questions)
+	assert.Equal(t, []string{"hash1"}, stored.AnswerHashes)
+}
+
+func TestBoltRepositoryPersistsRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "onboarding.db")
+	repo, err := NewBoltRepository(path)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(&Record{CorrelationId: "c1", Subject: "JonnyBoy", TokenId: "jti1", Status: StatusCompleted}))
+	require.NoError(t, repo.Close())
+
+	repo, err = NewBoltRepository(path)
+	require.NoError(t, err)
+	defer repo.Close()
+	stored, err := repo.Get("c1")
+	require.NoError(t, err)
+	assert.Equal(t, "JonnyBoy", stored.Subject)
+	assert.Equal(t, StatusCompleted, stored.Status)
+}
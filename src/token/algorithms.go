@@ -0,0 +1,136 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"strings"
+
+	"jwt-sign/configuration"
+	"jwt-sign/paseto"
+)
+
+// supportedAlgorithms are the algorithms accepted when no allowlist is configured.
+var supportedAlgorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+	paseto.PurposePublic, paseto.PurposeLocal,
+}
+
+// rejectedHeaders are the header parameters pointing the verifier at a key chosen by the token itself. Keys are only
+// ever taken from the configuration, a token carrying one of them is rejected rather than silently trusted.
+var rejectedHeaders = []string{"jku", "x5u", "jwk", "x5c"}
+
+// AlgorithmPolicy describes which signing algorithms are accepted in a token.
+type AlgorithmPolicy struct {
+	// Algorithms accepted from any issuer, every supported algorithm when empty. PASETO are accepted under their
+	// version and purpose, v4.public and v4.local
+	Algorithms []string
+	// Issuers maps an issuer to the algorithms accepted in its tokens, in place of Algorithms
+	Issuers map[string][]string
+}
+
+// NewAlgorithmPolicy creates the algorithm policy from the application configuration.
+//
+// Parameters:
+//   - conf *configuration.Configuration: application configuration holding the algorithm allowlists
+//
+// Returns:
+//   - AlgorithmPolicy: The policy
+//   - error: An error if an allowlist names an unsupported algorithm or an issuer entry is malformed
+func NewAlgorithmPolicy(conf *configuration.Configuration) (AlgorithmPolicy, error) {
+	p := AlgorithmPolicy{Issuers: map[string][]string{}}
+	if err := checkAlgorithms(conf.JwtAlgorithms); err != nil {
+		return p, err
+	}
+	p.Algorithms = conf.JwtAlgorithms
+	for _, entry := range conf.JwtIssuerAlgorithms {
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 || i == len(entry)-1 {
+			return p, fmt.Errorf("issuer algorithms %q are not in the issuer=ALG|ALG form", entry)
+		}
+		issuer, algorithms := entry[:i], strings.Split(entry[i+1:], "|")
+		if err := checkAlgorithms(algorithms); err != nil {
+			return p, fmt.Errorf("issuer %s: %s", issuer, err.Error())
+		}
+		p.Issuers[issuer] = algorithms
+	}
+	return p, nil
+}
+
+// Validate checks the algorithm of a token against the allowlist of its issuer, or the global allowlist.
+//
+// Parameters:
+//   - alg string: The alg header of the token
+//   - issuer string: The iss claim of the token, may be empty
+//
+// Returns:
+//   - error: An *Error if the algorithm is not accepted
+func (p AlgorithmPolicy) Validate(alg, issuer string) error {
+	if algorithms, ok := p.Issuers[issuer]; ok {
+		if !contains(algorithms, alg) {
+			return newError(ErrCodeAlgorithmRejected, "algorithm %s is not accepted for issuer %q", alg, issuer)
+		}
+		return nil
+	}
+	algorithms := p.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = supportedAlgorithms
+	}
+	if !contains(algorithms, alg) {
+		return newError(ErrCodeAlgorithmRejected, "algorithm %s is not accepted", alg)
+	}
+	return nil
+}
+
+// checkHeader rejects the header parameters selecting a key, and critical extensions since the verifier understands
+// none.
+func checkHeader(header map[string]interface{}) error {
+	for _, name := range rejectedHeaders {
+		if _, ok := header[name]; ok {
+			return newError(ErrCodeHeaderRejected, "header parameter %s is not accepted", name)
+		}
+	}
+	if _, ok := header["crit"]; ok {
+		return newError(ErrCodeHeaderRejected, "critical header extensions are not supported")
+	}
+	return nil
+}
+
+// keyAccepts reports whether the key may verify tokens signed with the algorithm, so that a key of one family, or an
+// EC key of another curve, is never handed to the signing method of the token.
+func keyAccepts(alg string, key interface{}) bool {
+	switch k := key.(type) {
+	case []byte:
+		return strings.HasPrefix(alg, "HS")
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		switch alg {
+		case "ES256":
+			return k.Curve == elliptic.P256()
+		case "ES384":
+			return k.Curve == elliptic.P384()
+		case "ES512":
+			return k.Curve == elliptic.P521()
+		}
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	}
+	return false
+}
+
+// checkAlgorithms rejects the algorithms the verifier does not support, "none" among them.
+func checkAlgorithms(algorithms []string) error {
+	for _, alg := range algorithms {
+		if !contains(supportedAlgorithms, alg) {
+			return fmt.Errorf("unsupported algorithm %q", alg)
+		}
+	}
+	return nil
+}
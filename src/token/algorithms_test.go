@@ -0,0 +1,199 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/configuration"
+	"jwt-sign/signer"
+)
+
+const testHmacSecret = "a-secret-long-enough-for-hs256-tokens"
+
+// newKeyVerifier creates a verifier trusting the HMAC secret and the public key of the returned RSA key.
+func newKeyVerifier(t *testing.T, configure func(conf *configuration.Configuration)) (*Verifier, *rsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicPem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	file := filepath.Join(t.TempDir(), "public.pem")
+	require.NoError(t, ioutil.WriteFile(file, publicPem, 0600))
+
+	conf := &configuration.Configuration{JwtHmacSecret: testHmacSecret, JwtPublicKeyFile: file}
+	if configure != nil {
+		configure(conf)
+	}
+	v, err := NewVerifier(conf, nil, nil)
+	require.NoError(t, err)
+	return v, key, publicPem
+}
+
+// rawToken serializes a token with the given header, whatever it holds, signed by the method with the key.
+func rawToken(t *testing.T, header map[string]interface{}, claims jwt.MapClaims, method jwt.SigningMethod, key interface{}) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	if method == nil {
+		return input + "."
+	}
+	signature, err := method.Sign(input, key)
+	require.NoError(t, err)
+	return input + "." + signature
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func assertRejected(t *testing.T, err error, code ErrorCode, msgAndArgs ...interface{}) {
+	t.Helper()
+	var tokenErr *Error
+	if assert.ErrorAs(t, err, &tokenErr, msgAndArgs...) {
+		assert.Equal(t, code, tokenErr.Code, msgAndArgs...)
+	}
+}
+
+func TestVerifyRejectsUnsignedTokens(t *testing.T) {
+	v, _, _ := newKeyVerifier(t, nil)
+
+	for _, alg := range []string{"none", "None", "NONE", ""} {
+		header := map[string]interface{}{"alg": alg, "typ": "JWT"}
+		_, err := v.Verify(rawToken(t, header, testClaims(), nil, nil))
+		assertRejected(t, err, ErrCodeUnsigned, alg)
+
+		// a signature does not make the token signed
+		_, err = v.Verify(rawToken(t, header, testClaims(), nil, nil) + "c2lnbmF0dXJl")
+		assertRejected(t, err, ErrCodeUnsigned, alg)
+	}
+
+	// none cannot be allowlisted either
+	_, err := NewVerifier(&configuration.Configuration{JwtAlgorithms: []string{"none"}}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestVerifyRejectsKeyConfusion(t *testing.T) {
+	v, key, publicPem := newKeyVerifier(t, nil)
+
+	// the configured keys verify their own algorithms
+	_, err := v.Verify(rawToken(t, map[string]interface{}{"alg": "HS256"}, testClaims(), jwt.SigningMethodHS256, []byte(testHmacSecret)))
+	require.NoError(t, err)
+	_, err = v.Verify(rawToken(t, map[string]interface{}{"alg": "RS256"}, testClaims(), jwt.SigningMethodRS256, key))
+	require.NoError(t, err)
+
+	// the public key is never used as an HMAC secret
+	forged := rawToken(t, map[string]interface{}{"alg": "HS256"}, testClaims(), jwt.SigningMethodHS256, publicPem)
+	_, err = v.Verify(forged)
+	assertRejected(t, err, ErrCodeSignatureInvalid)
+
+	v, _, publicPem = newKeyVerifier(t, func(conf *configuration.Configuration) {
+		conf.JwtHmacSecret = ""
+	})
+	forged = rawToken(t, map[string]interface{}{"alg": "HS256"}, testClaims(), jwt.SigningMethodHS256, publicPem)
+	_, err = v.Verify(forged)
+	assertRejected(t, err, ErrCodeUnverifiable)
+}
+
+func TestVerifyBindsAlgorithmToKey(t *testing.T) {
+	s, v := newLocalVerifier(t, nil, "RS256")
+	issued, err := s.Issue("JonnyBoy", nil, nil, time.Minute, "")
+	require.NoError(t, err)
+	_, err = v.Verify(issued.Token)
+	require.NoError(t, err)
+
+	// the key signs a valid signature under another algorithm of its family, which its kid does not allow
+	key, err := v.local.VerificationKey("kRS256")
+	require.NoError(t, err)
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodRS512, jwt.SigningMethodPS256} {
+		header := map[string]interface{}{"alg": method.Alg(), "kid": key.Id, "typ": signer.TypeAccessToken}
+		_, err = v.Verify(rawToken(t, header, testClaims(), method, key.Private))
+		assertRejected(t, err, ErrCodeUnverifiable, method.Alg())
+	}
+}
+
+func TestVerifyRejectsKeyHeaders(t *testing.T) {
+	v, _, _ := newKeyVerifier(t, nil)
+
+	for name, value := range map[string]interface{}{
+		"jku":  "https://attacker.example/jwks.json",
+		"x5u":  "https://attacker.example/cert.pem",
+		"jwk":  map[string]interface{}{"kty": "oct", "k": base64.RawURLEncoding.EncodeToString([]byte(testHmacSecret))},
+		"x5c":  []string{"MIIB"},
+		"crit": []string{"exp"},
+	} {
+		// the token is otherwise valid
+		header := map[string]interface{}{"alg": "HS256", name: value}
+		_, err := v.Verify(rawToken(t, header, testClaims(), jwt.SigningMethodHS256, []byte(testHmacSecret)))
+		assertRejected(t, err, ErrCodeHeaderRejected, name)
+	}
+}
+
+func TestVerifyIssuerAlgorithms(t *testing.T) {
+	v, key, _ := newKeyVerifier(t, func(conf *configuration.Configuration) {
+		conf.JwtAlgorithms = []string{"RS256"}
+		conf.JwtIssuerAlgorithms = []string{"https://legacy.example=HS256|RS256"}
+	})
+	hs256 := func(claims jwt.MapClaims, secret []byte) string {
+		return rawToken(t, map[string]interface{}{"alg": "HS256"}, claims, jwt.SigningMethodHS256, secret)
+	}
+	withIssuer := func(issuer string) jwt.MapClaims {
+		claims := testClaims()
+		claims["iss"] = issuer
+		return claims
+	}
+
+	_, err := v.Verify(rawToken(t, map[string]interface{}{"alg": "RS256"}, testClaims(), jwt.SigningMethodRS256, key))
+	require.NoError(t, err)
+	_, err = v.Verify(hs256(withIssuer("https://legacy.example"), []byte(testHmacSecret)))
+	require.NoError(t, err)
+
+	// HS256 is only accepted from the legacy issuer
+	_, err = v.Verify(hs256(testClaims(), []byte(testHmacSecret)))
+	assertRejected(t, err, ErrCodeAlgorithmRejected)
+	_, err = v.Verify(hs256(withIssuer("https://other.example"), []byte(testHmacSecret)))
+	assertRejected(t, err, ErrCodeAlgorithmRejected)
+
+	// claiming the legacy issuer selects its wider allowlist, but the signature must still verify
+	_, err = v.Verify(hs256(withIssuer("https://legacy.example"), []byte("a-secret-the-attacker-chose")))
+	assertRejected(t, err, ErrCodeSignatureInvalid)
+}
+
+func TestNewAlgorithmPolicy(t *testing.T) {
+	p, err := NewAlgorithmPolicy(&configuration.Configuration{
+		JwtAlgorithms:       []string{"ES256"},
+		JwtIssuerAlgorithms: []string{"https://idp.example/realms/a=b=RS256|PS256"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"https://idp.example/realms/a=b": {"RS256", "PS256"}}, p.Issuers)
+	assert.NoError(t, p.Validate("PS256", "https://idp.example/realms/a=b"))
+	assert.Error(t, p.Validate("ES256", "https://idp.example/realms/a=b"))
+	assert.NoError(t, p.Validate("ES256", ""))
+	assert.NoError(t, AlgorithmPolicy{}.Validate("EdDSA", ""))
+	assert.Error(t, AlgorithmPolicy{}.Validate("none", ""))
+
+	for _, conf := range []*configuration.Configuration{
+		{JwtAlgorithms: []string{"HS128"}},
+		{JwtIssuerAlgorithms: []string{"https://idp.example"}},
+		{JwtIssuerAlgorithms: []string{"=RS256"}},
+		{JwtIssuerAlgorithms: []string{"https://idp.example="}},
+		{JwtIssuerAlgorithms: []string{"https://idp.example=RS256|none"}},
+	} {
+		_, err = NewAlgorithmPolicy(conf)
+		assert.Error(t, err, "%v %v", conf.JwtAlgorithms, conf.JwtIssuerAlgorithms)
+	}
+}
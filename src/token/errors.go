@@ -0,0 +1,55 @@
+package token
+
+import "fmt"
+
+// ErrorCode identifies why a token was rejected, so clients can tell the failures apart.
+type ErrorCode string
+
+const (
+	// ErrCodeMalformed the token is not a well-formed compact JWS
+	ErrCodeMalformed ErrorCode = "token_malformed"
+	// ErrCodeUnsigned the token carries no signature or uses alg "none"
+	ErrCodeUnsigned ErrorCode = "token_unsigned"
+	// ErrCodeAlgorithmRejected the alg header is not in the allowlist of the issuer
+	ErrCodeAlgorithmRejected ErrorCode = "token_algorithm_rejected"
+	// ErrCodeHeaderRejected the header references a key of its own or a critical extension
+	ErrCodeHeaderRejected ErrorCode = "token_header_rejected"
+	// ErrCodeDecryptionFailed no encryption key of the key store decrypts the token
+	ErrCodeDecryptionFailed ErrorCode = "token_decryption_failed"
+	// ErrCodeUnverifiable no configured key can verify the token
+	ErrCodeUnverifiable ErrorCode = "token_unverifiable"
+	// ErrCodeSignatureInvalid the signature does not match the token content
+	ErrCodeSignatureInvalid ErrorCode = "token_signature_invalid"
+	// ErrCodeExpired the exp claim is in the past
+	ErrCodeExpired ErrorCode = "token_expired"
+	// ErrCodeNotYetValid the nbf or iat claim is in the future
+	ErrCodeNotYetValid ErrorCode = "token_not_yet_valid"
+	// ErrCodeInvalidIssuer the iss claim is not an accepted issuer
+	ErrCodeInvalidIssuer ErrorCode = "token_invalid_issuer"
+	// ErrCodeInvalidAudience the aud claim does not contain an accepted audience
+	ErrCodeInvalidAudience ErrorCode = "token_invalid_audience"
+	// ErrCodeClaimsInvalid a required claim is missing or has the wrong type
+	ErrCodeClaimsInvalid ErrorCode = "token_claims_invalid"
+	// ErrCodeRevoked the jti of the token was revoked
+	ErrCodeRevoked ErrorCode = "token_revoked"
+)
+
+// Error is returned by the verifier when a token is rejected.
+type Error struct {
+	Code ErrorCode
+	Err  error
+}
+
+// Error returns the error message prefixed with the error code.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Err.Error())
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(code ErrorCode, format string, a ...interface{}) *Error {
+	return &Error{Code: code, Err: fmt.Errorf(format, a...)}
+}
@@ -0,0 +1,107 @@
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2"
+	"jwt-sign/keystore"
+)
+
+// jweKeyAlgorithms are the key management algorithms accepted in encrypted tokens. RSA1_5 is left out for its padding
+// oracle, "dir" since every token must go through a key of the key store.
+var jweKeyAlgorithms = []string{"RSA-OAEP", "RSA-OAEP-256", "ECDH-ES", "ECDH-ES+A256KW", "A256KW"}
+
+// jweContentEncryptions are the content encryption algorithms accepted in encrypted tokens.
+var jweContentEncryptions = []string{"A256GCM"}
+
+// isEncrypted reports whether the token is a compact JWE, made of five segments where a JWS has three.
+func isEncrypted(raw string) bool {
+	return strings.Count(raw, ".") == 4
+}
+
+// decrypt decrypts a compact JWE with the encryption keys of the key store and returns the JWS it nests. An encrypted
+// token proves nothing about its issuer since anyone holding the public key can produce one, so only JWE with a
+// signed JWT as content are accepted.
+//
+// Parameters:
+//   - raw string: The compact serialized JWE
+//
+// Returns:
+//   - string: The nested compact JWS
+//   - error: An *Error describing why the token could not be decrypted
+func (v *Verifier) decrypt(raw string) (string, error) {
+	header, err := jweHeader(raw)
+	if err != nil {
+		return "", err
+	}
+	if err = checkHeader(header); err != nil {
+		return "", err
+	}
+	alg, _ := header["alg"].(string)
+	enc, _ := header["enc"].(string)
+	if !contains(jweKeyAlgorithms, alg) {
+		return "", newError(ErrCodeAlgorithmRejected, "key management algorithm %q is not accepted", alg)
+	}
+	if !contains(jweContentEncryptions, enc) {
+		return "", newError(ErrCodeAlgorithmRejected, "content encryption %q is not accepted", enc)
+	}
+	if cty, _ := header["cty"].(string); !strings.EqualFold(cty, "JWT") {
+		return "", newError(ErrCodeUnsigned, "encrypted token does not nest a signed jwt")
+	}
+
+	kid, _ := header["kid"].(string)
+	keys := v.decryptionKeys(kid, alg)
+	if len(keys) == 0 {
+		return "", newError(ErrCodeUnverifiable, "no decryption key for kid %q and %s", kid, alg)
+	}
+	jwe, err := jose.ParseEncrypted(raw)
+	if err != nil {
+		return "", newError(ErrCodeMalformed, "%s", err)
+	}
+	for _, key := range keys {
+		if plaintext, err := jwe.Decrypt(key.Private); err == nil {
+			return string(plaintext), nil
+		}
+	}
+	return "", newError(ErrCodeDecryptionFailed, "token could not be decrypted")
+}
+
+// decryptionKeys returns the encryption keys of the key store able to decrypt a JWE using alg: the key under kid when
+// the header names one, every key of the algorithm otherwise.
+func (v *Verifier) decryptionKeys(kid, alg string) []*keystore.Key {
+	if v.decryption == nil {
+		return nil
+	}
+	var keys []*keystore.Key
+	if kid != "" {
+		if key, err := v.decryption.VerificationKey(kid); err == nil {
+			keys = append(keys, key)
+		}
+	} else {
+		keys = v.decryption.Keys()
+	}
+	usable := keys[:0]
+	for _, key := range keys {
+		// the algorithm is bound to the key, never taken from the token header
+		if key.CanDecrypt() && key.Algorithm == alg {
+			usable = append(usable, key)
+		}
+	}
+	return usable
+}
+
+// jweHeader decodes the protected header of a compact JWE.
+func jweHeader(raw string) (map[string]interface{}, error) {
+	segment := raw[:strings.Index(raw, ".")]
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return nil, newError(ErrCodeMalformed, "invalid encrypted token header: %s", err.Error())
+	}
+	var header map[string]interface{}
+	if err = json.Unmarshal(data, &header); err != nil {
+		return nil, newError(ErrCodeMalformed, "invalid encrypted token header: %s", err.Error())
+	}
+	return header, nil
+}
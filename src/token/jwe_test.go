@@ -0,0 +1,111 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"jwt-sign/configuration"
+	"jwt-sign/keystore"
+)
+
+// newDecryptingVerifier creates a verifier trusting the HMAC secret, over a key store holding an RSA encryption key
+// "rsa-enc", an EC encryption key "ec-enc" and an RSA signing key "rsa-sig".
+func newDecryptingVerifier(t *testing.T) (*Verifier, map[string]interface{}) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signing, err := keystore.Generate("RS256", "rsa-sig")
+	require.NoError(t, err)
+
+	keys := []*keystore.Key{signing}
+	for _, jwk := range []jose.JSONWebKey{
+		{Key: rsaKey, KeyID: "rsa-enc", Algorithm: "RSA-OAEP-256", Use: keystore.UseEncryption},
+		{Key: ecKey, KeyID: "ec-enc", Algorithm: "ECDH-ES+A256KW", Use: keystore.UseEncryption},
+	} {
+		key, err := keystore.FromJwk(jwk)
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	v, err := NewVerifier(&configuration.Configuration{JwtHmacSecret: testHmacSecret},
+		keystore.NewMemoryKeyStore(signing.Id, keys...), nil)
+	require.NoError(t, err)
+	return v, map[string]interface{}{
+		"rsa-enc": &rsaKey.PublicKey,
+		"ec-enc":  &ecKey.PublicKey,
+		"rsa-sig": signing.Public,
+	}
+}
+
+// encryptToken nests the token in a compact JWE encrypted to the public key, with the given headers.
+func encryptToken(t *testing.T, token string, alg jose.KeyAlgorithm, enc jose.ContentEncryption, public interface{}, kid string, headers map[jose.HeaderKey]interface{}) string {
+	t.Helper()
+	options := &jose.EncrypterOptions{}
+	for k, value := range headers {
+		options = options.WithHeader(k, value)
+	}
+	encrypter, err := jose.NewEncrypter(enc, jose.Recipient{Algorithm: alg, Key: public, KeyID: kid}, options)
+	require.NoError(t, err)
+	jwe, err := encrypter.Encrypt([]byte(token))
+	require.NoError(t, err)
+	serialized, err := jwe.CompactSerialize()
+	require.NoError(t, err)
+	return serialized
+}
+
+func TestVerifyEncryptedTokens(t *testing.T) {
+	v, public := newDecryptingVerifier(t)
+	nested := rawToken(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}, testClaims(), jwt.SigningMethodHS256, []byte(testHmacSecret))
+	cty := map[jose.HeaderKey]interface{}{"cty": "JWT"}
+
+	for name, raw := range map[string]string{
+		"rsa":         encryptToken(t, nested, jose.RSA_OAEP_256, jose.A256GCM, public["rsa-enc"], "rsa-enc", cty),
+		"ec":          encryptToken(t, nested, jose.ECDH_ES_A256KW, jose.A256GCM, public["ec-enc"], "ec-enc", cty),
+		"without kid": encryptToken(t, nested, jose.RSA_OAEP_256, jose.A256GCM, public["rsa-enc"], "", cty),
+	} {
+		token, err := v.Verify(raw)
+		require.NoError(t, err, name)
+		assert.Equal(t, "JonnyBoy", token.Claims.(jwt.MapClaims)["sub"], name)
+	}
+}
+
+func TestVerifyRejectsEncryptedTokens(t *testing.T) {
+	v, public := newDecryptingVerifier(t)
+	nested := rawToken(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}, testClaims(), jwt.SigningMethodHS256, []byte(testHmacSecret))
+	unsigned := rawToken(t, map[string]interface{}{"alg": "none", "typ": "JWT"}, testClaims(), nil, nil)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	cty := map[jose.HeaderKey]interface{}{"cty": "JWT"}
+	jku := map[jose.HeaderKey]interface{}{"cty": "JWT", "jku": "https://attacker.example/jwks.json"}
+
+	for name, test := range map[string]struct {
+		raw  string
+		code ErrorCode
+	}{
+		"rsa1_5":  {encryptToken(t, nested, jose.RSA1_5, jose.A256GCM, public["rsa-enc"], "rsa-enc", cty), ErrCodeAlgorithmRejected},
+		"a128gcm": {encryptToken(t, nested, jose.RSA_OAEP_256, jose.A128GCM, public["rsa-enc"], "rsa-enc", cty), ErrCodeAlgorithmRejected},
+		// the algorithm is bound to the key
+		"other algorithm": {encryptToken(t, nested, jose.RSA_OAEP, jose.A256GCM, public["rsa-enc"], "rsa-enc", cty), ErrCodeUnverifiable},
+		// signing keys never decrypt
+		"signing key": {encryptToken(t, nested, jose.RSA_OAEP_256, jose.A256GCM, public["rsa-sig"], "rsa-sig", cty), ErrCodeUnverifiable},
+		"unknown kid": {encryptToken(t, nested, jose.RSA_OAEP_256, jose.A256GCM, public["rsa-enc"], "unknown", cty), ErrCodeUnverifiable},
+		"other key":   {encryptToken(t, nested, jose.RSA_OAEP_256, jose.A256GCM, &other.PublicKey, "rsa-enc", cty), ErrCodeDecryptionFailed},
+		// an encrypted token proves nothing about its issuer
+		"no content type":  {encryptToken(t, nested, jose.RSA_OAEP_256, jose.A256GCM, public["rsa-enc"], "rsa-enc", nil), ErrCodeUnsigned},
+		"nested unsigned":  {encryptToken(t, unsigned, jose.RSA_OAEP_256, jose.A256GCM, public["rsa-enc"], "rsa-enc", cty), ErrCodeUnsigned},
+		"key header":       {encryptToken(t, nested, jose.RSA_OAEP_256, jose.A256GCM, public["rsa-enc"], "rsa-enc", jku), ErrCodeHeaderRejected},
+		"malformed header": {base64.RawURLEncoding.EncodeToString([]byte("{")) + ".a.b.c.d", ErrCodeMalformed},
+	} {
+		_, err := v.Verify(test.raw)
+		assertRejected(t, err, test.code, name)
+	}
+}
@@ -0,0 +1,114 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"jwt-sign/paseto"
+)
+
+// pasetoMethod stands for the purpose of a PASETO in the token returned by Verify. It neither signs nor verifies.
+type pasetoMethod string
+
+// Alg returns the version and purpose of the PASETO.
+func (m pasetoMethod) Alg() string {
+	return string(m)
+}
+
+// Verify always fails, PASETO are verified by the paseto package.
+func (m pasetoMethod) Verify(string, string, interface{}) error {
+	return fmt.Errorf("%s tokens are not JWS", string(m))
+}
+
+// Sign always fails, PASETO are produced by the paseto package.
+func (m pasetoMethod) Sign(string, interface{}) (string, error) {
+	return "", fmt.Errorf("%s tokens are not JWS", string(m))
+}
+
+// verifyPaseto verifies a v4.public or decrypts a v4.local token, then applies the algorithm and claims policies and
+// the revocation check of JWT. The date claims, RFC 3339 strings in PASETO, are converted to NumericDates.
+//
+// Parameters:
+//   - raw string: The PASETO
+//
+// Returns:
+//   - *jwt.Token: The verified token, with claims of type jwt.MapClaims and the purpose as algorithm
+//   - error: An *Error describing why the token was rejected
+func (v *Verifier) verifyPaseto(raw string) (*jwt.Token, error) {
+	purpose := paseto.Purpose(raw)
+	if purpose == "" {
+		return nil, newError(ErrCodeMalformed, "%s", paseto.ErrUnsupported)
+	}
+	footer, err := paseto.Footer(raw)
+	if err != nil {
+		return nil, newError(ErrCodeMalformed, "%s", err)
+	}
+	kid := paseto.KeyId(footer)
+
+	var payload []byte
+	switch purpose {
+	case paseto.PurposePublic:
+		var key ed25519.PublicKey
+		if key, err = v.pasetoPublicKey(kid); err != nil {
+			return nil, newError(ErrCodeUnverifiable, "%s", err)
+		}
+		payload, err = paseto.Verify(key, raw, nil)
+	default:
+		if v.pasetoKey == nil {
+			return nil, newError(ErrCodeUnverifiable, "no paseto local key configured")
+		}
+		payload, err = paseto.Decrypt(v.pasetoKey, raw, nil)
+	}
+	if errors.Is(err, paseto.ErrInvalid) {
+		return nil, newError(ErrCodeSignatureInvalid, "%s", err)
+	} else if err != nil {
+		return nil, newError(ErrCodeMalformed, "%s", err)
+	}
+
+	claims := jwt.MapClaims{}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, newError(ErrCodeMalformed, "paseto payload is not a JSON object: %s", err.Error())
+	}
+	if err = paseto.ToNumericDates(claims); err != nil {
+		return nil, newError(ErrCodeClaimsInvalid, "%s", err)
+	}
+	issuer, _ := claims["iss"].(string)
+	if err = v.algorithms.Validate(purpose, issuer); err != nil {
+		return nil, err
+	}
+	if err = v.policy.Validate(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	if err = v.checkRevoked(claims); err != nil {
+		return nil, err
+	}
+	header := map[string]interface{}{"alg": purpose}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	return &jwt.Token{Raw: raw, Method: pasetoMethod(purpose), Header: header, Claims: claims, Valid: true}, nil
+}
+
+// pasetoPublicKey returns the Ed25519 key verifying v4.public tokens: the key named by the kid of the footer, or the
+// configured public key.
+func (v *Verifier) pasetoPublicKey(kid string) (ed25519.PublicKey, error) {
+	if kid != "" && (v.local != nil || v.remote != nil) {
+		key, _, err := v.resolve(kid)
+		if err != nil {
+			return nil, err
+		}
+		public, ok := key.Public.(ed25519.PublicKey)
+		if !ok || key.Algorithm != "EdDSA" {
+			return nil, fmt.Errorf("key %q is not an Ed25519 key", kid)
+		}
+		return public, nil
+	}
+	if public, ok := v.publicKey.(ed25519.PublicKey); ok {
+		return public, nil
+	}
+	return nil, fmt.Errorf("no Ed25519 public key configured for %s", paseto.PurposePublic)
+}
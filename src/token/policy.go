@@ -0,0 +1,209 @@
+package token
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"jwt-sign/configuration"
+)
+
+// ClaimsPolicy describes which registered claims must be present in a token and which values are accepted.
+type ClaimsPolicy struct {
+	RequireExp bool
+	RequireNbf bool
+	RequireIat bool
+	RequireSub bool
+	RequireJti bool
+	// Issuers accepted in the iss claim, any issuer is accepted when empty
+	Issuers []string
+	// Audiences of which at least one must be present in the aud claim, any audience is accepted when empty
+	Audiences []string
+	// Leeway tolerated clock skew when checking exp, nbf and iat
+	Leeway time.Duration
+}
+
+// NewClaimsPolicy creates the claims policy from the application configuration.
+func NewClaimsPolicy(conf *configuration.Configuration) ClaimsPolicy {
+	return ClaimsPolicy{
+		RequireExp: conf.JwtRequireExp,
+		RequireNbf: conf.JwtRequireNbf,
+		RequireIat: conf.JwtRequireIat,
+		RequireSub: conf.JwtRequireSub,
+		RequireJti: conf.JwtRequireJti,
+		Issuers:    conf.JwtIssuers,
+		Audiences:  conf.JwtAudiences,
+		Leeway:     time.Duration(conf.JwtLeewaySec) * time.Second,
+	}
+}
+
+// Validate checks the registered claims against the policy.
+//
+// Parameters:
+//   - claims jwt.MapClaims: The decoded token claims
+//   - now time.Time: The reference time for exp, nbf and iat
+//
+// Returns:
+//   - error: An *Error describing the first claim that failed, nil if the claims are valid
+func (p ClaimsPolicy) Validate(claims jwt.MapClaims, now time.Time) error {
+	exp, ok, err := numericDate(claims, "exp")
+	if err != nil {
+		return err
+	}
+	if !ok && p.RequireExp {
+		return newError(ErrCodeClaimsInvalid, "missing claim: exp")
+	}
+	if ok && !now.Before(exp.Add(p.Leeway)) {
+		return newError(ErrCodeExpired, "token expired at %s", exp.UTC().Format(time.RFC3339))
+	}
+
+	nbf, ok, err := numericDate(claims, "nbf")
+	if err != nil {
+		return err
+	}
+	if !ok && p.RequireNbf {
+		return newError(ErrCodeClaimsInvalid, "missing claim: nbf")
+	}
+	if ok && now.Add(p.Leeway).Before(nbf) {
+		return newError(ErrCodeNotYetValid, "token is not valid before %s", nbf.UTC().Format(time.RFC3339))
+	}
+
+	iat, ok, err := numericDate(claims, "iat")
+	if err != nil {
+		return err
+	}
+	if !ok && p.RequireIat {
+		return newError(ErrCodeClaimsInvalid, "missing claim: iat")
+	}
+	if ok && now.Add(p.Leeway).Before(iat) {
+		return newError(ErrCodeNotYetValid, "token was issued in the future at %s", iat.UTC().Format(time.RFC3339))
+	}
+
+	iss, err := stringClaim(claims, "iss")
+	if err != nil {
+		return err
+	}
+	if len(p.Issuers) > 0 && !contains(p.Issuers, iss) {
+		return newError(ErrCodeInvalidIssuer, "issuer %q is not accepted", iss)
+	}
+
+	aud, err := audienceClaim(claims)
+	if err != nil {
+		return err
+	}
+	if len(p.Audiences) > 0 && !intersects(p.Audiences, aud) {
+		return newError(ErrCodeInvalidAudience, "audience %q is not accepted", aud)
+	}
+
+	sub, err := stringClaim(claims, "sub")
+	if err != nil {
+		return err
+	}
+	if sub == "" && p.RequireSub {
+		return newError(ErrCodeClaimsInvalid, "missing claim: sub")
+	}
+
+	jti, err := stringClaim(claims, "jti")
+	if err != nil {
+		return err
+	}
+	if jti == "" && p.RequireJti {
+		return newError(ErrCodeClaimsInvalid, "missing claim: jti")
+	}
+	return nil
+}
+
+// AcceptedUntil returns the instant from which Validate rejects the token as expired, its exp claim plus the leeway
+// rounded up to the second. Whatever is kept about a token, its nonce or its revocation, must be kept until then.
+//
+// Parameters:
+//   - claims jwt.MapClaims: The decoded token claims
+//
+// Returns:
+//   - time.Time: The end of the validity of the token, the zero time for tokens without a valid exp which never expire
+func (p ClaimsPolicy) AcceptedUntil(claims jwt.MapClaims) time.Time {
+	exp, ok, err := numericDate(claims, "exp")
+	if err != nil || !ok {
+		return time.Time{}
+	}
+	until := exp.Add(p.Leeway)
+	if truncated := until.Truncate(time.Second); truncated.Before(until) {
+		return truncated.Add(time.Second)
+	}
+	return until
+}
+
+// numericDate reads a NumericDate claim. The boolean is false when the claim is absent.
+func numericDate(claims jwt.MapClaims, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok || v == nil {
+		return time.Time{}, false, nil
+	}
+	var seconds float64
+	switch n := v.(type) {
+	case float64:
+		seconds = n
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return time.Time{}, false, newError(ErrCodeClaimsInvalid, "claim %s is not a numeric date", name)
+		}
+		seconds = f
+	default:
+		return time.Time{}, false, newError(ErrCodeClaimsInvalid, "claim %s is not a numeric date", name)
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true, nil
+}
+
+// stringClaim reads a string claim, returning an empty string when the claim is absent.
+func stringClaim(claims jwt.MapClaims, name string) (string, error) {
+	v, ok := claims[name]
+	if !ok || v == nil {
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", newError(ErrCodeClaimsInvalid, "claim %s is not a string", name)
+	}
+	return s, nil
+}
+
+// audienceClaim reads the aud claim, which may be a single string or an array of strings.
+func audienceClaim(claims jwt.MapClaims) ([]string, error) {
+	switch aud := claims["aud"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{aud}, nil
+	case []interface{}:
+		result := make([]string, 0, len(aud))
+		for _, a := range aud {
+			s, ok := a.(string)
+			if !ok {
+				return nil, newError(ErrCodeClaimsInvalid, "claim aud contains a non string value")
+			}
+			result = append(result, s)
+		}
+		return result, nil
+	default:
+		return nil, newError(ErrCodeClaimsInvalid, "claim aud is not a string or an array of strings")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+func intersects(a, b []string) bool {
+	for _, s := range b {
+		if contains(a, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package token
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"jwt-sign/configuration"
+)
+
+func TestClaimsPolicyAcceptedUntil(t *testing.T) {
+	policy := ClaimsPolicy{Leeway: time.Minute}
+	exp := time.Unix(1700000000, 0)
+
+	until := policy.AcceptedUntil(jwt.MapClaims{"exp": float64(exp.Unix())})
+	assert.Equal(t, exp.Add(time.Minute), until)
+	// the policy accepts the token up to the last instant before until, and rejects it from then on
+	assert.NoError(t, policy.Validate(jwt.MapClaims{"exp": float64(exp.Unix())}, until.Add(-time.Nanosecond)))
+	assert.Error(t, policy.Validate(jwt.MapClaims{"exp": float64(exp.Unix())}, until))
+
+	// fractional dates are rounded up, so the token is never forgotten before the policy stops accepting it
+	assert.Equal(t, exp.Add(time.Minute+time.Second), policy.AcceptedUntil(jwt.MapClaims{"exp": float64(exp.Unix()) + 0.25}))
+
+	assert.True(t, policy.AcceptedUntil(jwt.MapClaims{}).IsZero())
+	assert.True(t, policy.AcceptedUntil(jwt.MapClaims{"exp": "tomorrow"}).IsZero())
+}
+
+func TestClaimsPolicyValidate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	at := func(d time.Duration) float64 { return float64(now.Add(d).Unix()) }
+	strict := ClaimsPolicy{
+		RequireExp: true,
+		RequireNbf: true,
+		RequireIat: true,
+		RequireSub: true,
+		RequireJti: true,
+		Issuers:    []string{"https://idp.example"},
+		Audiences:  []string{"jwt-sign", "onboarding"},
+		Leeway:     30 * time.Second,
+	}
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"exp": at(time.Minute), "nbf": at(0), "iat": at(0), "sub": "JonnyBoy", "jti": "jti1",
+			"iss": "https://idp.example", "aud": "jwt-sign"}
+	}
+	assert.NoError(t, strict.Validate(valid(), now))
+	// nothing is required by default
+	assert.NoError(t, ClaimsPolicy{}.Validate(jwt.MapClaims{}, now))
+
+	for name, test := range map[string]struct {
+		name  string
+		value interface{}
+		code  ErrorCode
+	}{
+		"within leeway":        {"exp", at(-29 * time.Second), ""},
+		"expired":              {"exp", at(-30 * time.Second), ErrCodeExpired},
+		"missing exp":          {"exp", nil, ErrCodeClaimsInvalid},
+		"exp as string":        {"exp", "1700000060", ErrCodeClaimsInvalid},
+		"nbf within leeway":    {"nbf", at(30 * time.Second), ""},
+		"not yet valid":        {"nbf", at(31 * time.Second), ErrCodeNotYetValid},
+		"missing nbf":          {"nbf", nil, ErrCodeClaimsInvalid},
+		"issued in the future": {"iat", at(31 * time.Second), ErrCodeNotYetValid},
+		"missing iat":          {"iat", nil, ErrCodeClaimsInvalid},
+		"other issuer":         {"iss", "https://attacker.example", ErrCodeInvalidIssuer},
+		"missing issuer":       {"iss", nil, ErrCodeInvalidIssuer},
+		"issuer as number":     {"iss", 1.0, ErrCodeClaimsInvalid},
+		"audience in list":     {"aud", []interface{}{"other", "onboarding"}, ""},
+		"other audience":       {"aud", []interface{}{"other"}, ErrCodeInvalidAudience},
+		"missing audience":     {"aud", nil, ErrCodeInvalidAudience},
+		"audience as number":   {"aud", []interface{}{"jwt-sign", 1.0}, ErrCodeClaimsInvalid},
+		"audience as object":   {"aud", map[string]interface{}{"aud": "jwt-sign"}, ErrCodeClaimsInvalid},
+		"missing sub":          {"sub", nil, ErrCodeClaimsInvalid},
+		"empty sub":            {"sub", "", ErrCodeClaimsInvalid},
+		"missing jti":          {"jti", nil, ErrCodeClaimsInvalid},
+		"exp as json number":   {"exp", json.Number("1700000060"), ""},
+		"invalid json number":  {"exp", json.Number("soon"), ErrCodeClaimsInvalid},
+		"fractional nbf":       {"nbf", at(0) + 0.5, ""},
+		"jti as number":        {"jti", 1.0, ErrCodeClaimsInvalid},
+	} {
+		claims := valid()
+		if test.value == nil {
+			delete(claims, test.name)
+		} else {
+			claims[test.name] = test.value
+		}
+		err := strict.Validate(claims, now)
+		if test.code == "" {
+			assert.NoError(t, err, name)
+		} else {
+			assertRejected(t, err, test.code, name)
+		}
+	}
+}
+
+func TestNewClaimsPolicy(t *testing.T) {
+	policy := NewClaimsPolicy(&configuration.Configuration{
+		JwtRequireExp: true,
+		JwtRequireJti: true,
+		JwtIssuers:    []string{"https://idp.example"},
+		JwtAudiences:  []string{"jwt-sign"},
+		JwtLeewaySec:  45,
+	})
+	assert.Equal(t, ClaimsPolicy{
+		RequireExp: true,
+		RequireJti: true,
+		Issuers:    []string{"https://idp.example"},
+		Audiences:  []string{"jwt-sign"},
+		Leeway:     45 * time.Second,
+	}, policy)
+}
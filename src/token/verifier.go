@@ -0,0 +1,305 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/danbordeanu/go-logger"
+	"github.com/danbordeanu/go-stats/concurrency"
+	"github.com/golang-jwt/jwt"
+	"jwt-sign/configuration"
+	"jwt-sign/keystore"
+	"jwt-sign/paseto"
+	"jwt-sign/revocation"
+	"jwt-sign/signer"
+)
+
+// Verifier parses compact JWS tokens and verifies their signature against the configured keys.
+type Verifier struct {
+	hmacSecret []byte
+	publicKey  interface{}
+	// remote resolves the kid of tokens issued by the identity provider, nil when no JWKS URL is configured
+	remote *keystore.RemoteJwks
+	// local resolves the kid of tokens issued by this service, nil unless token issuance is enabled
+	local keystore.KeyStore
+	// tokenKeyId is the only kid of local accepted for tokens, any local key is accepted when empty
+	tokenKeyId string
+	// decryption holds the encryption keys of encrypted tokens
+	decryption keystore.KeyStore
+	// pasetoKey decrypts v4.local tokens, nil when no key is configured
+	pasetoKey []byte
+	// revoked denylists the jti of revoked tokens, nil when revocation is not checked
+	revoked    revocation.Store
+	parser     *jwt.Parser
+	policy     ClaimsPolicy
+	algorithms AlgorithmPolicy
+}
+
+var verifier *Verifier
+
+// Init builds the package verifier from the application configuration. It must be called once at startup,
+// before any handler calls Verify. When a JWKS URL is configured its keys are fetched and refreshed in the
+// background until ctx is cancelled.
+func Init(ctx context.Context, conf *configuration.Configuration, local keystore.KeyStore, revoked revocation.Store) error {
+	log := logger.SugaredLogger().With("package", "token", "action", "Init")
+	v, err := NewVerifier(conf, local, revoked)
+	if err != nil {
+		return err
+	}
+	if v.remote != nil {
+		if err = v.remote.Refresh(ctx); err != nil {
+			log.Warnf("unable to fetch jwks from %s, keys will be fetched on demand: %s", conf.JwtJwksUrl, err.Error())
+		}
+		if conf.JwtJwksRefreshSec > 0 {
+			concurrency.GlobalWaitGroup.Add(1)
+			go v.remote.Watch(ctx)
+		}
+	}
+	verifier = v
+	return nil
+}
+
+// Verify parses and verifies a token using the package verifier initialized by Init.
+func Verify(raw string) (*jwt.Token, error) {
+	if verifier == nil {
+		return nil, newError(ErrCodeUnverifiable, "token verifier is not initialized")
+	}
+	return verifier.Verify(raw)
+}
+
+// AcceptedUntil returns the end of the validity of verified claims under the claims policy of the package verifier
+// initialized by Init, see ClaimsPolicy.AcceptedUntil.
+func AcceptedUntil(claims jwt.MapClaims) time.Time {
+	if verifier == nil {
+		return ClaimsPolicy{}.AcceptedUntil(claims)
+	}
+	return verifier.policy.AcceptedUntil(claims)
+}
+
+// NewVerifier creates a Verifier using the HMAC secret, the PEM encoded public key file, the JWKS URL and the
+// claims and algorithm policies from the configuration. Encrypted tokens are decrypted with the encryption keys of
+// the service key store, tokens signed by it are trusted only when token issuance is enabled.
+//
+// Parameters:
+//   - conf *configuration.Configuration: application configuration holding the key settings
+//   - local keystore.KeyStore: key store of the service
+//   - revoked revocation.Store: denylist of revoked tokens, may be nil
+//
+// Returns:
+//   - *Verifier: The verifier
+//   - error: An error if the public key file cannot be read or parsed, or the algorithm policy is invalid
+func NewVerifier(conf *configuration.Configuration, local keystore.KeyStore, revoked revocation.Store) (*Verifier, error) {
+	algorithms, err := NewAlgorithmPolicy(conf)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verifier{
+		decryption: local,
+		revoked:    revoked,
+		parser:     &jwt.Parser{SkipClaimsValidation: true},
+		policy:     NewClaimsPolicy(conf),
+		algorithms: algorithms,
+	}
+	if conf.TokenIssuanceEnabled {
+		v.local = local
+		v.tokenKeyId = conf.TokenSigningKeyId
+	}
+	if v.pasetoKey, err = paseto.ParseKey(conf.PasetoLocalKey); err != nil {
+		return nil, err
+	}
+	if conf.JwtHmacSecret != "" {
+		v.hmacSecret = []byte(conf.JwtHmacSecret)
+	}
+	if conf.JwtJwksUrl != "" {
+		v.remote = keystore.NewRemoteJwks(conf.JwtJwksUrl, nil,
+			time.Duration(conf.JwtJwksRefreshSec)*time.Second,
+			time.Duration(conf.JwtJwksMinRefetchSec)*time.Second,
+			time.Duration(conf.JwtJwksStaleIfErrorSec)*time.Second)
+	}
+	if conf.JwtPublicKeyFile != "" {
+		pemBytes, err := ioutil.ReadFile(conf.JwtPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading public key file %s: %s", conf.JwtPublicKeyFile, err.Error())
+		}
+		if v.publicKey, err = parsePublicKey(pemBytes); err != nil {
+			return nil, fmt.Errorf("error parsing public key file %s: %s", conf.JwtPublicKeyFile, err.Error())
+		}
+	}
+	return v, nil
+}
+
+// Verify checks that the token was signed by a trusted key, that its registered claims satisfy the policy and that it
+// was not revoked. An encrypted token is decrypted first and the nested JWS verified, a PASETO is verified by
+// verifyPaseto. The header of a JWS is checked against the algorithm policy before its signature is verified.
+//
+// Parameters:
+//   - raw string: The compact serialized token, a JWS, a JWE nesting a JWS or a v4 PASETO
+//
+// Returns:
+//   - *jwt.Token: The verified token, with claims of type jwt.MapClaims. Raw holds the JWS of an encrypted token
+//   - error: An *Error describing why the token was rejected
+func (v *Verifier) Verify(raw string) (*jwt.Token, error) {
+	raw = strings.TrimSpace(raw)
+	if paseto.IsToken(raw) {
+		return v.verifyPaseto(raw)
+	}
+	if isEncrypted(raw) {
+		nested, err := v.decrypt(raw)
+		if err != nil {
+			return nil, err
+		}
+		raw = strings.TrimSpace(nested)
+	}
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, newError(ErrCodeMalformed, "token contains %d segments instead of 3", len(parts))
+	}
+	if parts[2] == "" {
+		return nil, newError(ErrCodeUnsigned, "token has no signature")
+	}
+	unverified, _, err := v.parser.ParseUnverified(raw, jwt.MapClaims{})
+	if unverified == nil || unverified.Header == nil || unverified.Claims == nil {
+		return nil, newError(ErrCodeMalformed, "%s", err)
+	}
+	alg, _ := unverified.Header["alg"].(string)
+	if alg == "" || strings.EqualFold(alg, "none") {
+		return nil, newError(ErrCodeUnsigned, "token is not signed")
+	}
+	if err != nil {
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorMalformed != 0 {
+			return nil, newError(ErrCodeMalformed, "%s", err)
+		}
+		return nil, newError(ErrCodeUnverifiable, "%s", err)
+	}
+	if err = checkHeader(unverified.Header); err != nil {
+		return nil, err
+	}
+	// the issuer is not verified yet, it only selects the allowlist the signature must then satisfy
+	issuer, _ := unverified.Claims.(jwt.MapClaims)["iss"].(string)
+	if err = v.algorithms.Validate(alg, issuer); err != nil {
+		return nil, err
+	}
+
+	token, err := v.parser.Parse(raw, v.keyFunc)
+	if err != nil {
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorUnverifiable != 0 {
+			return nil, newError(ErrCodeUnverifiable, "%s", err)
+		}
+		return nil, newError(ErrCodeSignatureInvalid, "%s", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if err = v.policy.Validate(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	if err = v.checkRevoked(claims); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// keyFunc returns the key verifying the token, provided the key accepts the algorithm of the token.
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	key, err := v.key(token)
+	if err != nil {
+		return nil, err
+	}
+	if !keyAccepts(token.Method.Alg(), key) {
+		return nil, fmt.Errorf("key of type %T cannot verify %s", key, token.Method.Alg())
+	}
+	return key, nil
+}
+
+// key resolves the kid of the token through the service key store and the remote JWKS when configured,
+// otherwise it selects the configured key matching the signing method family of the token.
+func (v *Verifier) key(token *jwt.Token) (interface{}, error) {
+	if kid, _ := token.Header["kid"].(string); kid != "" && (v.local != nil || v.remote != nil) {
+		key, local, err := v.resolve(kid)
+		if err != nil {
+			return nil, err
+		}
+		// the keys of the service also sign answers, only what they issued as a token is a token
+		if typ, _ := token.Header["typ"].(string); local && !strings.EqualFold(typ, signer.TypeAccessToken) {
+			return nil, fmt.Errorf("token signed by key %q is typed %q instead of %s", kid, typ, signer.TypeAccessToken)
+		}
+		// the algorithm is bound to the key, never taken from the token header
+		if key.Algorithm != token.Method.Alg() {
+			return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+		}
+		return key.Public, nil
+	}
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if v.hmacSecret == nil {
+			return nil, fmt.Errorf("no HMAC secret configured for %s", token.Method.Alg())
+		}
+		return v.hmacSecret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if key, ok := v.publicKey.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	case *jwt.SigningMethodECDSA:
+		if key, ok := v.publicKey.(*ecdsa.PublicKey); ok {
+			return key, nil
+		}
+	case *jwt.SigningMethodEd25519:
+		if key, ok := v.publicKey.(ed25519.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no public key configured for %s", token.Method.Alg())
+}
+
+// checkRevoked rejects the token when the jti of its issuer is denylisted. A failing store rejects the token too.
+func (v *Verifier) checkRevoked(claims jwt.MapClaims) error {
+	issuer, _ := claims["iss"].(string)
+	jti, _ := claims["jti"].(string)
+	if v.revoked == nil || jti == "" {
+		return nil
+	}
+	revoked, err := v.revoked.IsRevoked(issuer, jti)
+	if err != nil {
+		return newError(ErrCodeUnverifiable, "error checking revocation of %s: %s", jti, err.Error())
+	}
+	if revoked {
+		return newError(ErrCodeRevoked, "token %s was revoked", jti)
+	}
+	return nil
+}
+
+// resolve looks the kid up in the service key store first, so own tokens never trigger a remote refetch. The boolean
+// is true when the key belongs to the service key store, which only the token signing key may sign tokens with when
+// one is configured.
+func (v *Verifier) resolve(kid string) (*keystore.Key, bool, error) {
+	if v.local != nil {
+		if key, err := v.local.VerificationKey(kid); err == nil {
+			if v.tokenKeyId != "" && kid != v.tokenKeyId {
+				return nil, true, fmt.Errorf("key %q does not sign tokens", kid)
+			}
+			return key, true, nil
+		} else if v.remote == nil {
+			return nil, false, err
+		}
+	}
+	key, err := v.remote.VerificationKey(kid)
+	return key, false, err
+}
+
+// parsePublicKey parses a PEM encoded RSA, ECDSA or Ed25519 public key or certificate.
+func parsePublicKey(pemBytes []byte) (interface{}, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(pemBytes); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(pemBytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported or invalid PEM public key")
+}
@@ -0,0 +1,184 @@
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/configuration"
+	"jwt-sign/keystore"
+	"jwt-sign/paseto"
+	"jwt-sign/signer"
+)
+
+// newLocalVerifier creates a signer and a verifier trusting the tokens it issues, over a key store holding a key for
+// every algorithm, the first one signing answers.
+func newLocalVerifier(t *testing.T, configure func(conf *configuration.Configuration), algorithms ...string) (*signer.Signer, *Verifier) {
+	t.Helper()
+	keys := make([]*keystore.Key, len(algorithms))
+	for i, alg := range algorithms {
+		key, err := keystore.Generate(alg, "k"+alg)
+		require.NoError(t, err)
+		keys[i] = key
+	}
+	local := keystore.NewMemoryKeyStore(keys[0].Id, keys...)
+	conf := &configuration.Configuration{
+		SigningIssuer:           "jwt-sign",
+		TokenIssuanceEnabled:    true,
+		TokenDefaultTtlSec:      900,
+		TokenMaxTtlSec:          3600,
+		JwtRequireExp:           true,
+		JwtRequireSub:           true,
+		PasetoLocalKey:          "707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f",
+		SignaturePasetoLocalKey: "909192939495969798999a9b9c9d9e9fa0a1a2a3a4a5a6a7a8a9aaabacadaeaf",
+	}
+	if configure != nil {
+		configure(conf)
+	}
+	s, err := signer.NewSigner(conf, local)
+	require.NoError(t, err)
+	v, err := NewVerifier(conf, local, nil)
+	require.NoError(t, err)
+	return s, v
+}
+
+func TestVerifyIssuedTokens(t *testing.T) {
+	s, v := newLocalVerifier(t, nil, "ES256")
+
+	issued, err := s.Issue("JonnyBoy", nil, nil, time.Minute, "")
+	require.NoError(t, err)
+	token, err := v.Verify(issued.Token)
+	require.NoError(t, err)
+	assert.Equal(t, signer.TypeAccessToken, token.Header["typ"])
+
+	// an answers signature is signed by the same key, but is not a token
+	signed, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, "")
+	require.NoError(t, err)
+	_, err = v.Verify(signed.Signature)
+	assert.Error(t, err)
+}
+
+func TestVerifyTokenSigningKey(t *testing.T) {
+	s, v := newLocalVerifier(t, func(conf *configuration.Configuration) {
+		conf.TokenSigningKeyId = "kES384"
+	}, "ES256", "ES384")
+
+	issued, err := s.Issue("JonnyBoy", nil, nil, time.Minute, "")
+	require.NoError(t, err)
+	_, err = v.Verify(issued.Token)
+	require.NoError(t, err)
+
+	// a token signed by the answers signing key is rejected, even typed as a token
+	key, err := v.local.VerificationKey("kES256")
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "JonnyBoy", "exp": time.Now().Add(time.Minute).Unix()})
+	forged.Header["kid"] = key.Id
+	forged.Header["typ"] = signer.TypeAccessToken
+	raw, err := forged.SignedString(key.Private)
+	require.NoError(t, err)
+	_, err = v.Verify(raw)
+	assert.Error(t, err)
+}
+
+func TestVerifyIssuedPaseto(t *testing.T) {
+	s, v := newLocalVerifier(t, nil, "EdDSA")
+	for _, format := range []string{paseto.PurposePublic, paseto.PurposeLocal} {
+		issued, err := s.Issue("JonnyBoy", nil, nil, time.Minute, format)
+		require.NoError(t, err, format)
+		_, err = v.Verify(issued.Token)
+		require.NoError(t, err, format)
+
+		// answers signatures are bound to their own assertion, and encrypted with their own key
+		signed, err := s.Sign("JonnyBoy", []string{"question1"}, []string{"answer1"}, format)
+		require.NoError(t, err, format)
+		_, err = v.Verify(signed.Signature)
+		var tokenErr *Error
+		require.ErrorAs(t, err, &tokenErr, format)
+		assert.Equal(t, ErrCodeSignatureInvalid, tokenErr.Code, format)
+	}
+}
+
+func TestVerifyConfiguredKeys(t *testing.T) {
+	v, key, _ := newKeyVerifier(t, nil)
+
+	for name, raw := range map[string]string{
+		"hmac": rawToken(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}, testClaims(), jwt.SigningMethodHS256, []byte(testHmacSecret)),
+		"rsa":  rawToken(t, map[string]interface{}{"alg": "RS256", "typ": "JWT"}, testClaims(), jwt.SigningMethodRS256, key),
+		"pss":  rawToken(t, map[string]interface{}{"alg": "PS256", "typ": "JWT"}, testClaims(), jwt.SigningMethodPS256, key),
+	} {
+		token, err := v.Verify(" " + raw + "\n")
+		require.NoError(t, err, name)
+		assert.Equal(t, "JonnyBoy", token.Claims.(jwt.MapClaims)["sub"], name)
+	}
+
+	// a key type with no configured key
+	ecKey, err := keystore.Generate("ES256", "")
+	require.NoError(t, err)
+	_, err = v.Verify(rawToken(t, map[string]interface{}{"alg": "ES256", "typ": "JWT"}, testClaims(), jwt.SigningMethodES256, ecKey.Private))
+	assertRejected(t, err, ErrCodeUnverifiable)
+}
+
+func TestVerifyRejectsMalformedTokens(t *testing.T) {
+	v, _, _ := newKeyVerifier(t, nil)
+	valid := rawToken(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}, testClaims(), jwt.SigningMethodHS256, []byte(testHmacSecret))
+	parts := strings.Split(valid, ".")
+
+	for name, raw := range map[string]string{
+		"empty":           "",
+		"not a token":     "JonnyBoy",
+		"two segments":    parts[0] + "." + parts[1],
+		"four segments":   valid + ".extra",
+		"header encoding": "!!!." + parts[1] + "." + parts[2],
+		"header json":     base64.RawURLEncoding.EncodeToString([]byte("{")) + "." + parts[1] + "." + parts[2],
+		"claims json":     parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte("[1]")) + "." + parts[2],
+	} {
+		_, err := v.Verify(raw)
+		assertRejected(t, err, ErrCodeMalformed, name)
+	}
+
+	_, err := v.Verify(parts[0] + "." + parts[1] + ".")
+	assertRejected(t, err, ErrCodeUnsigned)
+}
+
+func TestVerifyRejectsTamperedTokens(t *testing.T) {
+	v, key, _ := newKeyVerifier(t, nil)
+
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodHS256, jwt.SigningMethodRS256} {
+		var signingKey interface{} = key
+		if method == jwt.SigningMethodHS256 {
+			signingKey = []byte(testHmacSecret)
+		}
+		valid := rawToken(t, map[string]interface{}{"alg": method.Alg(), "typ": "JWT"}, testClaims(), method, signingKey)
+		parts := strings.Split(valid, ".")
+
+		claims := testClaims()
+		claims["sub"] = "admin"
+		forged, err := json.Marshal(claims)
+		require.NoError(t, err)
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		signature[0] ^= 1
+
+		for name, raw := range map[string]string{
+			"claims":    parts[0] + "." + base64.RawURLEncoding.EncodeToString(forged) + "." + parts[2],
+			"signature": parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(signature),
+			"other key": rawToken(t, map[string]interface{}{"alg": method.Alg(), "typ": "JWT"}, testClaims(), method, otherKey(t, method)),
+		} {
+			_, err = v.Verify(raw)
+			assertRejected(t, err, ErrCodeSignatureInvalid, "%s %s", method.Alg(), name)
+		}
+	}
+}
+
+// otherKey returns a key of the kind the method signs with, other than the configured one.
+func otherKey(t *testing.T, method jwt.SigningMethod) interface{} {
+	t.Helper()
+	key, err := keystore.Generate(method.Alg(), "")
+	require.NoError(t, err)
+	return key.Private
+}
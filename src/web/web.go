@@ -0,0 +1,142 @@
+package web
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"jwt-sign/i18n"
+)
+
+// embedded html templates, static assets and message catalogs shipped with the binary
+//
+//go:embed templates assets locales
+var embedded embed.FS
+
+const (
+	// templatesDir directory of the html templates, embedded and in the override directory
+	templatesDir = "templates"
+	// assetsDir directory of the static assets, embedded and in the override directory
+	assetsDir = "assets"
+	// localesDir directory of the message catalogs, embedded and in the override directory
+	localesDir = "locales"
+)
+
+// LoadCatalogs reads the embedded message catalogs. The catalogs found in the locales directory of overrideDir replace
+// the embedded messages of the same locale, or add new locales.
+//
+// Parameters:
+//   - overrideDir string: Branding directory, ignored when empty
+//
+// Returns:
+//   - *i18n.Bundle: The catalogs
+//   - error: An error if a catalog cannot be parsed
+func LoadCatalogs(overrideDir string) (*i18n.Bundle, error) {
+	locales, err := fs.Sub(embedded, localesDir)
+	if err != nil {
+		return nil, err
+	}
+	if overrideDir == "" {
+		return i18n.Load(locales)
+	}
+	return i18n.Load(locales, os.DirFS(filepath.Join(overrideDir, localesDir)))
+}
+
+// LoadTemplates parses the embedded html templates. The templates found in the templates directory of overrideDir
+// replace the embedded ones with the same file name, or add new ones. Templates translate their text with the t
+// function, e.g. {{ t .locale "expired.heading" }}.
+//
+// Parameters:
+//   - overrideDir string: Branding directory, ignored when empty
+//   - catalogs *i18n.Bundle: Message catalogs used by the t function
+//
+// Returns:
+//   - *template.Template: The templates, named after their file name
+//   - error: An error if a template cannot be parsed
+func LoadTemplates(overrideDir string, catalogs *i18n.Bundle) (*template.Template, error) {
+	tmpl, err := template.New("").Funcs(template.FuncMap{"t": catalogs.T}).ParseFS(embedded, templatesDir+"/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("error parsing embedded templates: %s", err.Error())
+	}
+	if overrideDir == "" {
+		return tmpl, nil
+	}
+	overrides, err := filepath.Glob(filepath.Join(overrideDir, templatesDir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides) == 0 {
+		return tmpl, nil
+	}
+	if tmpl, err = tmpl.ParseFiles(overrides...); err != nil {
+		return nil, fmt.Errorf("error parsing templates of %s: %s", overrideDir, err.Error())
+	}
+	return tmpl, nil
+}
+
+// Assets returns the embedded static assets. The files found in the assets directory of overrideDir shadow the
+// embedded ones with the same path.
+//
+// Parameters:
+//   - overrideDir string: Branding directory, ignored when empty
+//
+// Returns:
+//   - http.FileSystem: The assets, directories are not listed
+func Assets(overrideDir string) http.FileSystem {
+	sub, err := fs.Sub(embedded, assetsDir)
+	if err != nil {
+		// cannot happen, the directory is embedded
+		panic(err)
+	}
+	assets := http.FS(sub)
+	if overrideDir == "" {
+		return filesOnly{assets}
+	}
+	return filesOnly{overlay{http.Dir(filepath.Join(overrideDir, assetsDir)), assets}}
+}
+
+// overlay serves the files of upper, falling back on lower.
+type overlay struct {
+	upper http.FileSystem
+	lower http.FileSystem
+}
+
+func (o overlay) Open(name string) (http.File, error) {
+	f, err := o.upper.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return o.lower.Open(name)
+}
+
+// filesOnly hides the directories so the file server never lists them.
+type filesOnly struct {
+	fs http.FileSystem
+}
+
+func (f filesOnly) Open(name string) (http.File, error) {
+	file, err := f.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		//goland:noinspection GoUnhandledErrorResult
+		file.Close()
+		return nil, err
+	}
+	if stat.IsDir() {
+		//goland:noinspection GoUnhandledErrorResult
+		file.Close()
+		return nil, os.ErrNotExist
+	}
+	return file, nil
+}
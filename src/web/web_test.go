@@ -0,0 +1,217 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"jwt-sign/i18n"
+	"jwt-sign/questionnaire"
+)
+
+// templateKeys matches the message keys the templates translate.
+var templateKeys = regexp.MustCompile(`t \$?\.locale "([^"]+)"`)
+
+// formatVerbs matches the fmt verbs of a message.
+var formatVerbs = regexp.MustCompile(`%[a-z]`)
+
+// embeddedCatalogs reads the embedded catalogs by locale.
+func embeddedCatalogs(t *testing.T) map[string]i18n.Catalog {
+	t.Helper()
+	files, err := fs.Glob(embedded, localesDir+"/*.json")
+	require.NoError(t, err)
+	catalogs := map[string]i18n.Catalog{}
+	for _, file := range files {
+		data, err := fs.ReadFile(embedded, file)
+		require.NoError(t, err)
+		var catalog i18n.Catalog
+		require.NoError(t, json.Unmarshal(data, &catalog), file)
+		catalogs[strings.TrimSuffix(filepath.Base(file), ".json")] = catalog
+	}
+	return catalogs
+}
+
+func TestEmbeddedCatalogsAreComplete(t *testing.T) {
+	catalogs, err := LoadCatalogs("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en", "de", "fr", "ro"}, catalogs.Locales())
+	assert.Empty(t, catalogs.Missing())
+
+	// no catalog keeps a message the fallback dropped, nor changes the arguments of a message
+	embeddedCatalogs := embeddedCatalogs(t)
+	fallback := embeddedCatalogs[i18n.Fallback]
+	for locale, catalog := range embeddedCatalogs {
+		for key, message := range catalog {
+			if assert.Contains(t, fallback, key, "%s has a message unknown to %s", locale, i18n.Fallback) {
+				assert.Equal(t, formatVerbs.FindAllString(fallback[key], -1), formatVerbs.FindAllString(message, -1),
+					"arguments of %s in %s", key, locale)
+			}
+		}
+	}
+}
+
+func TestEmbeddedCatalogsTranslateEveryKey(t *testing.T) {
+	fallback := embeddedCatalogs(t)[i18n.Fallback]
+
+	templates, err := fs.Glob(embedded, templatesDir+"/*.html")
+	require.NoError(t, err)
+	for _, file := range templates {
+		data, err := fs.ReadFile(embedded, file)
+		require.NoError(t, err)
+		for _, match := range templateKeys.FindAllSubmatch(data, -1) {
+			assert.Contains(t, fallback, string(match[1]), file)
+		}
+	}
+
+	// the field errors are translated under field.<code>
+	for _, code := range []string{questionnaire.CodeCountMismatch, questionnaire.CodeUnknownQuestionnaire,
+		questionnaire.CodeUnknownQuestion, questionnaire.CodeDuplicateQuestion, questionnaire.CodeRequired,
+		questionnaire.CodeInvalidType, questionnaire.CodeNotAllowed, questionnaire.CodePatternMismatch} {
+		assert.Contains(t, fallback, "field."+code)
+	}
+}
+
+func TestTemplatesRenderEveryLocale(t *testing.T) {
+	catalogs, err := LoadCatalogs("")
+	require.NoError(t, err)
+	tmpl, err := LoadTemplates("", catalogs)
+	require.NoError(t, err)
+
+	for _, locale := range catalogs.Locales() {
+		for _, page := range tmpl.Templates() {
+			if page.Name() == "" {
+				continue
+			}
+			var out bytes.Buffer
+			err = page.Execute(&out, map[string]interface{}{
+				"locale":        locale,
+				"status":        "successfully",
+				"testSignature": "signature",
+				"kid":           "k1",
+				"alg":           "ES256",
+				"errorCode":     "token_expired",
+				"correlationId": "0a1b2c",
+				"fields":        []map[string]string{{"Field": "answers[0]", "Code": questionnaire.CodeRequired}},
+			})
+			require.NoError(t, err, "%s in %s", page.Name(), locale)
+			// a message with the wrong arguments renders fmt errors
+			assert.NotContains(t, out.String(), "%!", "%s in %s", page.Name(), locale)
+		}
+	}
+}
+
+func TestOverrideCatalogs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, localesDir), 0700))
+	write := func(name, content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, localesDir, name), []byte(content), 0600))
+	}
+	write("de.json", `{"expired.heading": "Abgelaufen"}`)
+	write("it.json", `{"expired.heading": "Token scaduto"}`)
+
+	catalogs, err := LoadCatalogs(dir)
+	require.NoError(t, err)
+	// an override replaces single messages and keeps the embedded ones
+	assert.Equal(t, "Abgelaufen", catalogs.T("de", "expired.heading"))
+	assert.Equal(t, embeddedCatalogs(t)["de"]["used.heading"], catalogs.T("de", "used.heading"))
+	// a new locale falls back on the missing messages, which are reported
+	assert.Equal(t, "Token scaduto", catalogs.T("it", "expired.heading"))
+	assert.Equal(t, embeddedCatalogs(t)[i18n.Fallback]["used.heading"], catalogs.T("it", "used.heading"))
+	missing := catalogs.Missing()
+	assert.Equal(t, []string{"it"}, keys(missing))
+	assert.Contains(t, missing["it"], "used.heading")
+	assert.NotContains(t, missing["it"], "expired.heading")
+
+	write("fr.json", `{"expired.heading": }`)
+	_, err = LoadCatalogs(dir)
+	assert.Error(t, err)
+}
+
+func keys(m map[string][]string) []string {
+	list := make([]string, 0, len(m))
+	for k := range m {
+		list = append(list, k)
+	}
+	return list
+}
+
+func TestLoadTemplates(t *testing.T) {
+	catalogs, err := LoadCatalogs("")
+	require.NoError(t, err)
+	tmpl, err := LoadTemplates("", catalogs)
+	require.NoError(t, err)
+	for _, page := range []string{"jwtvalidated.html", "jwtexpired.html", "jwtalreadyused.html", "registrationfailed.html"} {
+		assert.NotNil(t, tmpl.Lookup(page), page)
+	}
+
+	// an override replaces the embedded template of the same name, or adds a new one
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, templatesDir), 0700))
+	write := func(name, content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, templatesDir, name), []byte(content), 0600))
+	}
+	write("jwtexpired.html", `branded {{ t .locale "expired.heading" }}`)
+	write("imprint.html", `imprint`)
+	tmpl, err = LoadTemplates(dir, catalogs)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, tmpl.ExecuteTemplate(&out, "jwtexpired.html", map[string]string{"locale": "en"}))
+	assert.Equal(t, "branded "+catalogs.T("en", "expired.heading"), out.String())
+	assert.NotNil(t, tmpl.Lookup("imprint.html"))
+	assert.NotNil(t, tmpl.Lookup("jwtvalidated.html"))
+
+	write("jwtvalidated.html", `{{ .status `)
+	_, err = LoadTemplates(dir, catalogs)
+	assert.Error(t, err)
+}
+
+func TestAssets(t *testing.T) {
+	read := func(assets http.FileSystem, name string) (string, error) {
+		f, err := assets.Open(name)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		data, err := ioutil.ReadAll(f)
+		return string(data), err
+	}
+	embeddedStyle, err := fs.ReadFile(embedded, assetsDir+"/style.css")
+	require.NoError(t, err)
+
+	style, err := read(Assets(""), "/style.css")
+	require.NoError(t, err)
+	assert.Equal(t, string(embeddedStyle), style)
+	// directories are never listed
+	_, err = read(Assets(""), "/")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	_, err = read(Assets(""), "/missing.css")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	// the override directory shadows the embedded files and adds its own
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, assetsDir, "img"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, assetsDir, "logo.svg"), []byte("<svg/>"), 0600))
+	assets := Assets(dir)
+	style, err = read(assets, "/style.css")
+	require.NoError(t, err)
+	assert.Equal(t, string(embeddedStyle), style)
+	logo, err := read(assets, "/logo.svg")
+	require.NoError(t, err)
+	assert.Equal(t, "<svg/>", logo)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, assetsDir, "style.css"), []byte("body{}"), 0600))
+	style, err = read(assets, "/style.css")
+	require.NoError(t, err)
+	assert.Equal(t, "body{}", style)
+	_, err = read(assets, "/img")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}